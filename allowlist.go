@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+const (
+	packetLoginDisconnect = 0x00
+
+	DefaultAllowlistReason = "You are not on this tunnel's allowlist."
+)
+
+// AllowlistConfig restricts which players the host lets through the tunnel.
+// Entries are player names (case-insensitive) or UUIDs (with or without dashes).
+type AllowlistConfig struct {
+	Enabled bool     `json:"enabled"`
+	Players []string `json:"players"`
+	Reason  string   `json:"reason"`
+}
+
+func normalizeAllowlistEntry(entry string) string {
+	entry = strings.ToLower(strings.TrimSpace(entry))
+	if len(entry) == 36 && strings.Count(entry, "-") == 4 {
+		entry = strings.ReplaceAll(entry, "-", "")
+	}
+	return entry
+}
+
+// allows reports whether the player matches an entry by name or UUID
+func (c AllowlistConfig) allows(player *PlayerInfo) bool {
+	if !c.Enabled {
+		return true
+	}
+	name := normalizeAllowlistEntry(player.Name)
+	uuid := normalizeAllowlistEntry(player.UUID)
+	for _, entry := range c.Players {
+		e := normalizeAllowlistEntry(entry)
+		if e == "" {
+			continue
+		}
+		if e == name || (uuid != "" && e == uuid) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c AllowlistConfig) reason() string {
+	if strings.TrimSpace(c.Reason) == "" {
+		return DefaultAllowlistReason
+	}
+	return c.Reason
+}
+
+// loginDisconnectPacket builds a Login Disconnect packet with a readable reason
+func loginDisconnectPacket(reason string) []byte {
+	text, _ := json.Marshal(map[string]string{"text": reason})
+	return encodePacket(packetLoginDisconnect, appendString(nil, string(text)))
+}
+
+// GetAllowlist returns the host's player allowlist
+func (a *App) GetAllowlist() AllowlistConfig {
+	a.allowlistMu.Lock()
+	defer a.allowlistMu.Unlock()
+	cfg := a.allowlist
+	cfg.Players = append([]string{}, cfg.Players...)
+	return cfg
+}
+
+// SetAllowlist replaces the host's player allowlist and saves it in the
+// settings; it applies to the next login
+func (a *App) SetAllowlist(cfg AllowlistConfig) error {
+	cfg = a.setAllowlist(cfg)
+	return a.saveSettingsChange(func(s *Settings) { s.Security.Allowlist = cfg })
+}
+
+func (a *App) setAllowlist(cfg AllowlistConfig) AllowlistConfig {
+	players := []string{}
+	for _, p := range cfg.Players {
+		if p = strings.TrimSpace(p); p != "" {
+			players = append(players, p)
+		}
+	}
+	cfg.Players = players
+
+	a.allowlistMu.Lock()
+	a.allowlist = cfg
+	a.allowlistMu.Unlock()
+	return cfg
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+)
+
+func TestAllowlistMatchesNamesAndUUIDs(t *testing.T) {
+	cfg := AllowlistConfig{Enabled: true, Players: []string{"alex", "069a79f444e94026bff38c5e1f8e2e0f"}}
+
+	if !cfg.allows(&PlayerInfo{Name: "Alex"}) {
+		t.Fatal("Expected name match to be case-insensitive")
+	}
+	if !cfg.allows(&PlayerInfo{Name: "Notch", UUID: "069a79f4-44e9-4026-bff3-8c5e1f8e2e0f"}) {
+		t.Fatal("Expected dashed UUID to match undashed entry")
+	}
+	if cfg.allows(&PlayerInfo{Name: "Steve"}) {
+		t.Fatal("Expected unlisted player to be rejected")
+	}
+	if !(AllowlistConfig{}).allows(&PlayerInfo{Name: "Steve"}) {
+		t.Fatal("Expected disabled allowlist to allow everyone")
+	}
+}
+
+func TestLoginDisconnectPacketCarriesReason(t *testing.T) {
+	id, payload, err := readPacket(bufio.NewReader(bytes.NewReader(loginDisconnectPacket("Go away"))))
+	if err != nil || id != packetLoginDisconnect {
+		t.Fatalf("Expected Login Disconnect packet, got id=0x%02x err=%v", id, err)
+	}
+	reason, err := readString(bytes.NewReader(payload))
+	if err != nil || reason != `{"text":"Go away"}` {
+		t.Fatalf("Unexpected reason %q (%v)", reason, err)
+	}
+}
+
+func TestSetAllowlistDropsBlankEntries(t *testing.T) {
+	app := &App{ctx: testContext()}
+	app.SetAllowlist(AllowlistConfig{Enabled: true, Players: []string{" Alex ", ""}})
+	if got := app.GetAllowlist().Players; len(got) != 1 || got[0] != "Alex" {
+		t.Fatalf("Unexpected players: %v", got)
+	}
+}
+
+func TestHostStreamRejectsBeforeDialingServer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer ln.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		if conn, err := ln.Accept(); err == nil {
+			accepted <- conn
+		}
+	}()
+
+	app := &App{ctx: testContext()}
+	app.SetAllowlist(AllowlistConfig{Enabled: true, Players: []string{"Alex"}})
+	stream := app.newHostStream(&webrtc.DataChannel{}, ln.Addr().String())
+	defer stream.close()
+
+	stream.fromTunnel(loginStream(765, "Steve", testPlayerUUID))
+	select {
+	case <-accepted:
+		t.Fatal("Rejected player must not reach the Minecraft server")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	allowed := loginStream(765, "Alex", testPlayerUUID)
+	stream.fromTunnel(allowed)
+	select {
+	case conn := <-accepted:
+		defer conn.Close()
+		got := make([]byte, len(allowed))
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		if _, err := io.ReadFull(conn, got); err != nil || !bytes.Equal(got, allowed) {
+			t.Fatalf("Expected allowed login to be forwarded unchanged, got %x (%v)", got, err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected allowed player to reach the Minecraft server")
+	}
+}
+
+func TestHostStreamAllowlistFailsClosed(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer ln.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		if conn, err := ln.Accept(); err == nil {
+			accepted <- conn
+		}
+	}()
+
+	app := &App{ctx: testContext()}
+	app.SetAllowlist(AllowlistConfig{Enabled: true, Players: []string{"Alex"}})
+	stream := app.newHostStream(&webrtc.DataChannel{}, ln.Addr().String())
+	defer stream.close()
+
+	// A transfer is a login too, and a stream that does not parse is refused
+	stream.fromTunnel(handshakeStream(766, handshakeStateTransfer, "Mallory", testPlayerUUID))
+	stream.fromTunnel([]byte{0x05, 0x7f, 0x01, 0x02, 0x03, 0x04})
+	select {
+	case conn := <-accepted:
+		conn.Close()
+		t.Fatal("Unchecked connections must not reach the Minecraft server")
+	case <-time.After(200 * time.Millisecond):
+	}
+	if stream.player != nil {
+		t.Errorf("Expected no player, got %+v", stream.player)
+	}
+}
@@ -1,13 +1,17 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net"
 	"os"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pion/webrtc/v3"
@@ -19,6 +23,9 @@ type contextKey string
 
 const testModeKey contextKey = "testMode"
 
+// Local port the joiner's Minecraft client connects to, unless changed in Settings
+const DefaultJoinerPort = "42517"
+
 // We exchange these JSON blobs to connect
 type Signal struct {
 	SDP string `json:"sdp"`
@@ -31,6 +38,71 @@ type App struct {
 	joinerConns    map[net.Conn]struct{}
 	joinerConnMu   sync.Mutex
 	listener       net.Listener
+	dataChannel    *webrtc.DataChannel
+	traffic        trafficCounters
+	statsMu        sync.Mutex
+	lastStats      TunnelStats
+	lastStatsAt    time.Time
+	statusMu       sync.Mutex
+	status         *cachedStatus
+
+	targetMu      sync.Mutex
+	targetAddress string
+	lanAddress    string
+	lanMu         sync.Mutex
+	lanWorlds     map[string]LANWorld
+	lanDiscovery  net.PacketConn
+	mdnsAddr      string
+	lanSignalMu   sync.Mutex
+	lanSignal     *lanSignaling
+	folderMu      sync.Mutex
+	folderWatch   *folderWatch
+	allowlist     AllowlistConfig
+	allowlistMu   sync.Mutex
+	hostStream    *hostStream
+	proxyProtocol atomic.Bool
+
+	portMapMu        sync.Mutex
+	portMappings     []PortMapping
+	mappingListeners []io.Closer
+
+	controlMu sync.Mutex
+	control   *controlSession
+
+	chatMu      sync.Mutex
+	chatHistory []ChatMessage
+
+	rconMu      sync.Mutex
+	rconConfig  RCONConfig
+	rcon        *rconClient
+	rconPending map[int64]chan controlMessage
+
+	serverPropsMu   sync.Mutex
+	serverProps     *ServerProperties
+	serverPropsStop chan struct{}
+
+	serverProcMu sync.Mutex
+	serverProc   *serverProcess
+	serverState  string
+
+	configDir     string
+	settingsMu    sync.Mutex
+	settings      *Settings
+	activeProfile *Profile
+
+	profilesMu sync.Mutex
+	peersMu    sync.Mutex
+
+	identityMu sync.Mutex
+	identity   *webrtc.Certificate
+
+	historyMu     sync.Mutex
+	session       *sessionTracker
+	historyFileMu sync.Mutex
+
+	clipboardMu   sync.Mutex
+	clipboardStop chan struct{}
+	readClipboard func() (string, error) // replaces the Wails clipboard in tests
 }
 
 type PeerConnectionManager struct {
@@ -70,6 +142,7 @@ func NewApp() *App {
 
 func (a *App) startup(ctx context.Context) {
 	a.ctx, a.cancel = context.WithCancel(ctx)
+	a.loadSettings()
 }
 
 func (a *App) shutdown(ctx context.Context) {
@@ -80,13 +153,34 @@ func (a *App) shutdown(ctx context.Context) {
 		a.listener.Close()
 		a.listener = nil
 	}
+	a.StopLANDiscovery()
+	a.StopLANSignaling()
+	a.StopFolderSignaling()
+	a.StopClipboardWatch()
+	a.stopMappingListeners()
+	a.stopServerPropertiesWatch()
+	a.endSession("Closed the app")
+	a.sayGoodbye("Peer closed the app")
+	a.StopServer()
+	a.rconMu.Lock()
+	if a.rcon != nil {
+		a.rcon.Close()
+		a.rcon = nil
+	}
+	a.rconMu.Unlock()
 	if a.peerConnection != nil {
 		a.peerConnection.Close()
 		a.peerConnection = nil
 	}
 }
 
+// CreateOffer starts hosting with the settings, without a profile
 func (a *App) CreateOffer() (string, error) {
+	a.setActiveProfile(nil)
+	return a.createOffer()
+}
+
+func (a *App) createOffer() (string, error) {
 	defer func() {
 		if r := recover(); r != nil {
 			fmt.Fprintf(os.Stderr, "[PANIC] CreateOffer recovered: %v\n", r)
@@ -94,10 +188,22 @@ func (a *App) CreateOffer() (string, error) {
 		}
 	}()
 
+	// Every way of hosting comes through here, so none of them can hand a
+	// joiner an offer for a server that is down
+	if _, err := a.pingTarget(); err != nil {
+		return "", err
+	}
+
+	// Probe the NAT while ICE gathers so the host is warned before sharing the offer
+	natChecked := make(chan struct{})
+	go func() {
+		defer close(natChecked)
+		a.warnIfDirectUnlikely()
+	}()
+
 	config := webrtc.Configuration{
-		ICEServers: []webrtc.ICEServer{
-			{URLs: []string{"stun:stun.l.google.com:19302"}},
-		},
+		ICEServers:   a.iceServers(),
+		Certificates: a.certificates(),
 	}
 
 	peerConnection, err := webrtc.NewPeerConnection(config)
@@ -113,19 +219,29 @@ func (a *App) CreateOffer() (string, error) {
 	}()
 
 	a.peerConnection = peerConnection
+	a.newSession(ProfileRoleHost, a.target())
 
 	dataChannel, err := peerConnection.CreateDataChannel("minecraft", nil)
 	if err != nil {
 		return "", err
 	}
 
+	channelClosed := make(chan struct{})
+
 	dataChannel.OnOpen(func() {
+		a.dataChannel = dataChannel
 		a.safeEventEmit("status-change", "connected")
 		a.safeEventEmit("log", "P2P Tunnel Established!")
+		go a.startStatsSampler(channelClosed)
 		go a.pumpMinecraftToChannel(dataChannel)
 	})
 
 	dataChannel.OnClose(func() {
+		close(channelClosed)
+		if a.hostStream != nil {
+			a.hostStream.close()
+		}
+		a.endSession("Connection closed")
 		a.safeEventEmit("status-change", "disconnected")
 		a.safeEventEmit("log", "DataChannel closed")
 	})
@@ -133,12 +249,36 @@ func (a *App) CreateOffer() (string, error) {
 	dataChannel.OnMessage(func(msg webrtc.DataChannelMessage) {
 	})
 
+	controlChannel, err := peerConnection.CreateDataChannel(ControlChannelLabel, nil)
+	if err != nil {
+		return "", err
+	}
+	a.openControlSession(controlChannel, true)
+
+	// The joiner opens one DataChannel per TCP connection and one per UDP
+	// mapping on extra port mappings
+	peerConnection.OnDataChannel(func(dc *webrtc.DataChannel) {
+		switch {
+		case strings.HasPrefix(dc.Label(), tcpStreamLabelPrefix):
+			a.handleMappedStream(dc)
+		case strings.HasPrefix(dc.Label(), udpChannelLabelPrefix):
+			a.handleUDPChannel(dc)
+		default:
+			rejectChannel(dc)
+		}
+	})
+
 	peerConnection.OnConnectionStateChange(func(state webrtc.PeerConnectionState) {
 		switch state {
+		case webrtc.PeerConnectionStateConnected:
+			a.markSessionConnected()
 		case webrtc.PeerConnectionStateDisconnected:
-			a.safeEventEmit("status-change", "disconnected")
-			a.safeEventEmit("log", "Peer disconnected")
+			// Often transient: ICE may recover, or move on to failed
+			a.safeEventEmit("log", "Connection to peer interrupted")
+		case webrtc.PeerConnectionStateClosed:
+			a.endSession("Connection closed")
 		case webrtc.PeerConnectionStateFailed:
+			a.endSession("Connection failed")
 			a.safeEventEmit("status-change", "error")
 			a.safeEventEmit("log", "Connection failed")
 		}
@@ -154,12 +294,13 @@ func (a *App) CreateOffer() (string, error) {
 	}
 
 	gatheringDone := webrtc.GatheringCompletePromise(peerConnection)
+	iceTimeout := TimeoutWebRTCICE.Load()
 	select {
 	case <-gatheringDone:
-	case <-time.After(TimeoutWebRTCICE):
+	case <-time.After(iceTimeout):
 		cleanupNeeded = false
 		peerConnection.Close()
-		return "", fmt.Errorf("ICE gathering timeout: failed to gather candidates after %v", TimeoutWebRTCICE)
+		return "", fmt.Errorf("ICE gathering timeout: failed to gather candidates after %v", iceTimeout)
 	}
 
 	offerJson, err := json.Marshal(peerConnection.LocalDescription())
@@ -167,6 +308,8 @@ func (a *App) CreateOffer() (string, error) {
 		return "", fmt.Errorf("failed to marshal offer: %w", err)
 	}
 
+	<-natChecked
+
 	cleanupNeeded = false
 	return base64.StdEncoding.EncodeToString(offerJson), nil
 }
@@ -196,7 +339,13 @@ func (a *App) AcceptAnswer(answerToken string) error {
 	return nil
 }
 
+// AcceptOffer joins with the settings, without a profile
 func (a *App) AcceptOffer(offerToken string) (string, error) {
+	a.setActiveProfile(nil)
+	return a.acceptOffer(offerToken)
+}
+
+func (a *App) acceptOffer(offerToken string) (string, error) {
 	defer func() {
 		if r := recover(); r != nil {
 			fmt.Fprintf(os.Stderr, "[PANIC] AcceptOffer recovered: %v\n", r)
@@ -215,9 +364,8 @@ func (a *App) AcceptOffer(offerToken string) (string, error) {
 	}
 
 	config := webrtc.Configuration{
-		ICEServers: []webrtc.ICEServer{
-			{URLs: []string{"stun:stun.l.google.com:19302"}},
-		},
+		ICEServers:   a.iceServers(),
+		Certificates: a.certificates(),
 	}
 
 	peerConnection, err := webrtc.NewPeerConnection(config)
@@ -233,15 +381,30 @@ func (a *App) AcceptOffer(offerToken string) (string, error) {
 	}()
 
 	a.peerConnection = peerConnection
+	a.newSession(ProfileRoleJoin, net.JoinHostPort("localhost", a.joinerPort()))
 
 	peerConnection.OnDataChannel(func(dc *webrtc.DataChannel) {
+		if dc.Label() == ControlChannelLabel {
+			a.openControlSession(dc, false)
+			return
+		}
+
+		channelClosed := make(chan struct{})
+
 		dc.OnOpen(func() {
+			a.dataChannel = dc
 			a.safeEventEmit("status-change", "connected")
 			a.safeEventEmit("log", "P2P Tunnel Established!")
-			go a.StartJoinerProxy(dc, "42517")
+			go a.startStatsSampler(channelClosed)
+			go a.StartJoinerProxy(dc, a.joinerPort())
+			if err := a.startLANAnnouncer(a.joinerPort(), channelClosed); err != nil {
+				a.safeEventEmit("log", fmt.Sprintf("LAN announcement disabled: %v", err))
+			}
 		})
 
 		dc.OnClose(func() {
+			close(channelClosed)
+			a.endSession("Connection closed")
 			a.safeEventEmit("status-change", "disconnected")
 			a.safeEventEmit("log", "Connection closed")
 		})
@@ -249,10 +412,15 @@ func (a *App) AcceptOffer(offerToken string) (string, error) {
 
 	peerConnection.OnConnectionStateChange(func(state webrtc.PeerConnectionState) {
 		switch state {
+		case webrtc.PeerConnectionStateConnected:
+			a.markSessionConnected()
 		case webrtc.PeerConnectionStateDisconnected:
-			a.safeEventEmit("status-change", "disconnected")
-			a.safeEventEmit("log", "Peer disconnected")
+			// Often transient: ICE may recover, or move on to failed
+			a.safeEventEmit("log", "Connection to peer interrupted")
+		case webrtc.PeerConnectionStateClosed:
+			a.endSession("Connection closed")
 		case webrtc.PeerConnectionStateFailed:
+			a.endSession("Connection failed")
 			a.safeEventEmit("status-change", "error")
 			a.safeEventEmit("log", "Connection failed")
 		}
@@ -272,11 +440,12 @@ func (a *App) AcceptOffer(offerToken string) (string, error) {
 	}
 
 	gatheringDone := webrtc.GatheringCompletePromise(peerConnection)
+	iceTimeout := TimeoutWebRTCICE.Load()
 	select {
 	case <-gatheringDone:
-	case <-time.After(TimeoutWebRTCICE):
+	case <-time.After(iceTimeout):
 		peerConnection.Close()
-		return "", fmt.Errorf("ICE gathering timeout: failed to gather candidates after %v", TimeoutWebRTCICE)
+		return "", fmt.Errorf("ICE gathering timeout: failed to gather candidates after %v", iceTimeout)
 	}
 
 	answerJson, err := json.Marshal(peerConnection.LocalDescription())
@@ -292,60 +461,29 @@ func (a *App) AcceptOffer(offerToken string) (string, error) {
 
 // Helper: Connects DataChannel <-> Local Minecraft
 func (a *App) pumpMinecraftToChannel(dc *webrtc.DataChannel) {
-	// Connect to local Minecraft Server
-	mcConn, err := DialTimeout("tcp", "localhost:42517", TimeoutTCPConnect)
-	if err != nil {
-		a.safeEventEmit("status-change", "error")
-		a.safeEventEmit("log", fmt.Sprintf("Error connecting to Minecraft server: %v", err))
-		return
-	}
-	defer mcConn.Close()
-
-	// 1. Minecraft -> WebRTC Tunnel
-	go func() {
-		buf := make([]byte, 1500)
-		for {
-			n, err := mcConn.Read(buf)
-			if err != nil {
-				return
-			}
-			// Send raw bytes over WebRTC
-			dc.Send(buf[:n])
-		}
-	}()
+	// The Minecraft server is dialed once the first login passes inspection
+	stream := a.newHostStream(dc, a.target())
 
-	// 2. WebRTC Tunnel -> Minecraft
+	// WebRTC Tunnel -> Minecraft (Minecraft -> WebRTC is pumped by the stream)
 	dc.OnMessage(func(msg webrtc.DataChannelMessage) {
-		mcConn.Write(msg.Data)
+		a.traffic.addReceived(len(msg.Data))
+		stream.fromTunnel(msg.Data)
 	})
 
-	// Keep blocking until closed
-	select {}
+	a.hostStream = stream
 }
 
 func (a *App) StartHostProxy(dc *webrtc.DataChannel, targetAddress string) error {
-	mcConn, err := DialTimeout("tcp", targetAddress, TimeoutTCPConnect)
-	if err != nil {
+	stream := a.newHostStream(dc, targetAddress)
+	if err := stream.connect(); err != nil {
 		a.safeEventEmit("log", fmt.Sprintf("Error connecting to Minecraft server: %v", err))
 		return fmt.Errorf("cannot connect to Minecraft server: %w", err)
 	}
-	defer mcConn.Close()
-
-	// Minecraft -> WebRTC
-	go func() {
-		buf := make([]byte, 4096)
-		for {
-			n, err := mcConn.Read(buf)
-			if err != nil {
-				return
-			}
-			dc.Send(buf[:n])
-		}
-	}()
 
 	// WebRTC -> Minecraft
 	dc.OnMessage(func(msg webrtc.DataChannelMessage) {
-		mcConn.Write(msg.Data)
+		a.traffic.addReceived(len(msg.Data))
+		stream.fromTunnel(msg.Data)
 	})
 
 	return nil
@@ -355,6 +493,7 @@ func (a *App) StartJoinerProxy(dc *webrtc.DataChannel, port string) error {
 	a.joinerConns = make(map[net.Conn]struct{})
 
 	dc.OnMessage(func(msg webrtc.DataChannelMessage) {
+		a.traffic.addReceived(len(msg.Data))
 		a.joinerConnMu.Lock()
 		defer a.joinerConnMu.Unlock()
 		for conn := range a.joinerConns {
@@ -385,24 +524,51 @@ func (a *App) StartJoinerProxy(dc *webrtc.DataChannel, port string) error {
 }
 
 func (a *App) handleJoinerConnection(conn net.Conn, dc *webrtc.DataChannel) {
+	defer conn.Close()
+
+	// Peek at the handshake so server list pings are answered locally
+	// instead of colliding with real sessions on the tunnel
+	r := bufio.NewReader(conn)
+	conn.SetReadDeadline(time.Now().Add(TimeoutTCPOperation.Load()))
+	first, err := r.Peek(1)
+	if err != nil || first[0] == legacyPingByte {
+		return
+	}
+	id, payload, err := readPacket(r)
+	if err != nil {
+		return
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	if hs, err := parseHandshake(payload); err == nil && id == packetHandshake && hs.NextState == handshakeStateStatus {
+		a.serveCachedStatus(conn, r, hs)
+		return
+	}
+
 	a.joinerConnMu.Lock()
 	a.joinerConns[conn] = struct{}{}
 	a.joinerConnMu.Unlock()
+	a.traffic.activeStreams.Add(1)
 
 	defer func() {
-		conn.Close()
+		a.traffic.activeStreams.Add(-1)
 		a.joinerConnMu.Lock()
 		delete(a.joinerConns, conn)
 		a.joinerConnMu.Unlock()
 	}()
 
+	handshakePacket := encodePacket(id, payload)
+	dc.Send(handshakePacket)
+	a.traffic.addSent(len(handshakePacket))
+
 	buf := make([]byte, 4096)
 	for {
-		n, err := conn.Read(buf)
+		n, err := r.Read(buf)
 		if err != nil {
 			return
 		}
 		dc.Send(buf[:n])
+		a.traffic.addSent(n)
 	}
 }
 
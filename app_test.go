@@ -9,6 +9,21 @@ import (
 	"github.com/pion/webrtc/v3"
 )
 
+// TestMain points the user config directory at a temporary one, so apps
+// without a configDir never touch the real settings or known peers
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "minecraft-tunnel-test")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("XDG_CONFIG_HOME", dir)
+	os.Setenv("HOME", dir)
+	os.Setenv("AppData", dir)
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
 func testContext() context.Context {
 	ctx := context.Background()
 	return context.WithValue(ctx, testModeKey, true)
@@ -23,6 +38,7 @@ func TestNewPeerConnectionManager(t *testing.T) {
 
 func TestCreateOfferGeneratesValidBase64(t *testing.T) {
 	app := &App{ctx: testContext()}
+	startTestTarget(t, app)
 	token, err := app.CreateOffer()
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
@@ -39,6 +55,7 @@ func TestCreateOfferGeneratesValidBase64(t *testing.T) {
 
 func TestAcceptOfferGeneratesAnswer(t *testing.T) {
 	hostApp := &App{ctx: testContext()}
+	startTestTarget(t, hostApp)
 
 	// Create a real offer token
 	offerToken, err := hostApp.CreateOffer()
@@ -65,6 +82,7 @@ func TestAcceptOfferGeneratesAnswer(t *testing.T) {
 
 func TestAcceptAnswerSetsRemoteDescription(t *testing.T) {
 	hostApp := &App{ctx: testContext()}
+	startTestTarget(t, hostApp)
 
 	// Create offer
 	offerToken, err := hostApp.CreateOffer()
@@ -94,6 +112,7 @@ func TestCreateOfferClosesPeerConnectionOnError(t *testing.T) {
 	initialCount := len(initialFiles)
 
 	app := &App{ctx: testContext()}
+	startTestTarget(t, app)
 
 	for i := 0; i < 5; i++ {
 		offer, err := app.CreateOffer()
@@ -120,6 +139,7 @@ func TestCreateOfferClosesPeerConnectionOnError(t *testing.T) {
 
 func TestCreateOfferWithoutShutdownLeaksConnection(t *testing.T) {
 	app := &App{ctx: testContext()}
+	startTestTarget(t, app)
 
 	offer, err := app.CreateOffer()
 	if err != nil {
@@ -143,6 +163,7 @@ func TestCreateOfferWithoutShutdownLeaksConnection(t *testing.T) {
 
 func TestCreateOfferHandlesCreateOfferError(t *testing.T) {
 	app := &App{ctx: testContext()}
+	startTestTarget(t, app)
 
 	offer, err := app.CreateOffer()
 	if err != nil {
@@ -161,6 +182,7 @@ func TestCreateOfferHandlesCreateOfferError(t *testing.T) {
 
 func TestAcceptOfferHandlesSetRemoteDescriptionError(t *testing.T) {
 	hostApp := &App{ctx: testContext()}
+	startTestTarget(t, hostApp)
 
 	offerToken, err := hostApp.CreateOffer()
 	if err != nil {
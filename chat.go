@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	MaxChatMessageLength = 500
+
+	// Messages kept for GetChatHistory
+	chatHistoryLimit = 200
+)
+
+// ChatMessage is one line of chat between the tunnel peers
+type ChatMessage struct {
+	Text     string `json:"text"`
+	SentAt   int64  `json:"sentAt"`
+	FromPeer bool   `json:"fromPeer"`
+	From     string `json:"from,omitempty"`
+}
+
+func (a *App) recordChat(msg ChatMessage) {
+	a.chatMu.Lock()
+	defer a.chatMu.Unlock()
+	a.chatHistory = append(a.chatHistory, msg)
+	if len(a.chatHistory) > chatHistoryLimit {
+		a.chatHistory = a.chatHistory[len(a.chatHistory)-chatHistoryLimit:]
+	}
+}
+
+// receiveChat stores a message from the peer and emits it as "chat-message".
+// from is the peer's display name, empty if it has not set one.
+func (a *App) receiveChat(from, text string, sentAt int64) {
+	text = strings.TrimSpace(text)
+	if text == "" || utf8.RuneCountInString(text) > MaxChatMessageLength {
+		return
+	}
+	if sentAt == 0 {
+		sentAt = time.Now().UnixMilli()
+	}
+	msg := ChatMessage{Text: text, SentAt: sentAt, FromPeer: true, From: from}
+	a.recordChat(msg)
+	a.safeEventEmit("chat-message", msg)
+}
+
+// SendChatMessage sends text to the peer over the control channel. It works
+// as soon as the peer connection is up, before any Minecraft traffic.
+func (a *App) SendChatMessage(text string) (ChatMessage, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return ChatMessage{}, fmt.Errorf("message is empty")
+	}
+	if utf8.RuneCountInString(text) > MaxChatMessageLength {
+		return ChatMessage{}, fmt.Errorf("message is longer than %d characters", MaxChatMessageLength)
+	}
+
+	a.controlMu.Lock()
+	s := a.control
+	a.controlMu.Unlock()
+	if s == nil || s.negotiated() == 0 {
+		return ChatMessage{}, fmt.Errorf("not connected to a peer")
+	}
+	if !s.hasPeerFeature("chat") {
+		return ChatMessage{}, fmt.Errorf("peer does not support chat")
+	}
+
+	msg := ChatMessage{Text: text, SentAt: time.Now().UnixMilli()}
+	if err := s.send(controlMessage{Type: controlTypeChat, Text: text, SentAt: msg.SentAt}); err != nil {
+		return ChatMessage{}, fmt.Errorf("failed to send chat message: %w", err)
+	}
+	a.recordChat(msg)
+	return msg, nil
+}
+
+// GetChatHistory returns the most recent chat messages, oldest first
+func (a *App) GetChatHistory() []ChatMessage {
+	a.chatMu.Lock()
+	defer a.chatMu.Unlock()
+	return append([]ChatMessage{}, a.chatHistory...)
+}
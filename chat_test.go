@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSendChatMessageRequiresPeer(t *testing.T) {
+	app := &App{ctx: testContext()}
+	if _, err := app.SendChatMessage("hello"); err == nil {
+		t.Fatal("Expected error without a peer")
+	}
+}
+
+func TestSendChatMessageRejectsEmptyAndLong(t *testing.T) {
+	app := &App{ctx: testContext()}
+	if _, err := app.SendChatMessage("   "); err == nil {
+		t.Error("Expected empty message to be rejected")
+	}
+	if _, err := app.SendChatMessage(strings.Repeat("a", MaxChatMessageLength+1)); err == nil {
+		t.Error("Expected long message to be rejected")
+	}
+}
+
+func TestChatHistoryIsBounded(t *testing.T) {
+	app := &App{ctx: testContext()}
+	for i := 0; i < chatHistoryLimit+10; i++ {
+		app.receiveChat("", "hi", int64(i))
+	}
+	history := app.GetChatHistory()
+	if len(history) != chatHistoryLimit || history[0].SentAt != 10 {
+		t.Fatalf("Expected the newest %d messages, got %d starting at %d", chatHistoryLimit, len(history), history[0].SentAt)
+	}
+}
+
+func TestChatReachesPeerBeforeMinecraft(t *testing.T) {
+	host := &App{ctx: testContext()}
+	joiner := &App{ctx: testContext()}
+	connectTestApps(t, host, joiner)
+
+	ok := waitFor(t, 5*time.Second, func() bool {
+		s := host.currentControl()
+		return s != nil && s.negotiated() != 0
+	})
+	if !ok {
+		t.Fatal("Control channel never negotiated")
+	}
+
+	sent, err := host.SendChatMessage("  server's restarting ")
+	if err != nil {
+		t.Fatalf("SendChatMessage failed: %v", err)
+	}
+	if sent.Text != "server's restarting" || sent.FromPeer {
+		t.Errorf("Unexpected sent message %+v", sent)
+	}
+
+	ok = waitFor(t, 5*time.Second, func() bool {
+		history := joiner.GetChatHistory()
+		return len(history) == 1 && history[0].FromPeer && history[0].Text == "server's restarting" && history[0].SentAt == sent.SentAt
+	})
+	if !ok {
+		t.Fatalf("Expected joiner to receive chat, got %+v", joiner.GetChatHistory())
+	}
+}
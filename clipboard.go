@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	ClipboardPollInterval = time.Second
+
+	TokenKindOffer  = "offer"
+	TokenKindAnswer = "answer"
+)
+
+// DetectedToken is emitted on "token-detected" when a usable offer or
+// answer is copied
+type DetectedToken struct {
+	Kind  string `json:"kind"` // offer or answer
+	Role  string `json:"role"` // what accepting makes us: join for an offer, host for an answer
+	Token string `json:"token"`
+	// SessionID is the SDP session ID, the same on every copy of one token
+	SessionID   string `json:"sessionId"`
+	Fingerprint string `json:"fingerprint"`
+	// PeerName is set when the fingerprint belongs to a known peer
+	PeerName string `json:"peerName,omitempty"`
+}
+
+// inspectToken decodes text as an offer or answer token, the way
+// AcceptOffer and AcceptAnswer would
+func inspectToken(text string) (DetectedToken, bool) {
+	token := strings.TrimSpace(text)
+	if token == "" || len(token) > maxSignalingTokenSize {
+		return DetectedToken{}, false
+	}
+	data, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return DetectedToken{}, false
+	}
+	var desc webrtc.SessionDescription
+	if err := json.Unmarshal(data, &desc); err != nil || !strings.HasPrefix(desc.SDP, "v=0") {
+		return DetectedToken{}, false
+	}
+
+	d := DetectedToken{Token: token, SessionID: sdpSessionID(desc.SDP), Fingerprint: sdpFingerprint(desc.SDP)}
+	switch desc.Type {
+	case webrtc.SDPTypeOffer:
+		d.Kind, d.Role = TokenKindOffer, ProfileRoleJoin
+	case webrtc.SDPTypeAnswer:
+		d.Kind, d.Role = TokenKindAnswer, ProfileRoleHost
+	default:
+		return DetectedToken{}, false
+	}
+	return d, true
+}
+
+// sdpSessionID is the sess-id field of the SDP origin line,
+// o=<username> <sess-id> <sess-version> ...
+func sdpSessionID(sdp string) string {
+	for _, line := range strings.Split(sdp, "\n") {
+		if origin, ok := strings.CutPrefix(strings.TrimSpace(line), "o="); ok {
+			if fields := strings.Fields(origin); len(fields) > 1 {
+				return fields[1]
+			}
+		}
+	}
+	return ""
+}
+
+func (a *App) clipboardText() (string, error) {
+	if a.readClipboard != nil {
+		return a.readClipboard()
+	}
+	if a.ctx == nil || a.ctx.Value(testModeKey) == true {
+		return "", fmt.Errorf("clipboard unavailable")
+	}
+	return runtime.ClipboardGetText(a.ctx)
+}
+
+// awaitingAnswer reports whether we have sent an offer and have no answer yet
+func (a *App) awaitingAnswer() bool {
+	pc := a.peerConnection
+	return pc != nil && pc.SignalingState() == webrtc.SignalingStateHaveLocalOffer
+}
+
+// checkClipboardToken decides whether a detected token is worth offering
+// to the user: not one of our own, and an answer only while we wait for one
+func (a *App) checkClipboardToken(d *DetectedToken) bool {
+	if id, err := a.GetIdentity(); err == nil && d.Fingerprint == id.Fingerprint {
+		return false
+	}
+	if d.Kind == TokenKindAnswer && !a.awaitingAnswer() {
+		return false
+	}
+	if peers, err := a.ListKnownPeers(); err == nil {
+		for _, p := range peers {
+			if p.Fingerprint == d.Fingerprint {
+				d.PeerName = p.DisplayName
+			}
+		}
+	}
+	return true
+}
+
+// StartClipboardWatch polls the clipboard and emits "token-detected" when a
+// new offer or answer token is copied
+func (a *App) StartClipboardWatch() {
+	a.clipboardMu.Lock()
+	defer a.clipboardMu.Unlock()
+	if a.clipboardStop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	a.clipboardStop = stop
+	go a.watchClipboard(stop)
+}
+
+// StopClipboardWatch stops polling the clipboard
+func (a *App) StopClipboardWatch() {
+	a.clipboardMu.Lock()
+	defer a.clipboardMu.Unlock()
+	if a.clipboardStop != nil {
+		close(a.clipboardStop)
+		a.clipboardStop = nil
+	}
+}
+
+// SetWatchClipboard starts or stops the watcher and saves the choice in the
+// settings
+func (a *App) SetWatchClipboard(enabled bool) error {
+	if enabled {
+		a.StartClipboardWatch()
+	} else {
+		a.StopClipboardWatch()
+	}
+	return a.saveSettingsChange(func(s *Settings) { s.WatchClipboard = enabled })
+}
+
+func (a *App) watchClipboard(stop <-chan struct{}) {
+	ticker := time.NewTicker(ClipboardPollInterval)
+	defer ticker.Stop()
+
+	// Whatever is on the clipboard when watching starts was copied before
+	// and is not news
+	last, _ := a.clipboardText()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		if d, ok := a.pollClipboard(&last); ok {
+			a.safeEventEmit("token-detected", d)
+		}
+	}
+}
+
+// pollClipboard reads the clipboard once and returns a token worth showing,
+// if it holds one that was not there at the last poll
+func (a *App) pollClipboard(last *string) (DetectedToken, bool) {
+	text, err := a.clipboardText()
+	if err != nil || text == *last {
+		return DetectedToken{}, false
+	}
+	*last = text
+	d, ok := inspectToken(text)
+	if !ok || !a.checkClipboardToken(&d) {
+		return DetectedToken{}, false
+	}
+	return d, true
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/pion/webrtc/v3"
+)
+
+func TestInspectToken(t *testing.T) {
+	for _, text := range []string{"", "hello", "aGVsbG8=", base64.StdEncoding.EncodeToString([]byte(`{"type":"offer","sdp":"nope"}`))} {
+		if _, ok := inspectToken(text); ok {
+			t.Errorf("Expected %q not to be a token", text)
+		}
+	}
+
+	sdp := "v=0\r\no=- 4215748493727282385 2 IN IP4 127.0.0.1\r\na=fingerprint:sha-256 AB:CD\r\n"
+	data, _ := json.Marshal(webrtc.SessionDescription{Type: webrtc.SDPTypeOffer, SDP: sdp})
+	offer := base64.StdEncoding.EncodeToString(data)
+	d, ok := inspectToken("  " + offer + "\n")
+	if !ok {
+		t.Fatal("Expected the offer to be recognized")
+	}
+	if d.Kind != TokenKindOffer || d.Role != ProfileRoleJoin || d.Token != offer {
+		t.Errorf("Unexpected token %+v", d)
+	}
+	if d.SessionID != "4215748493727282385" || d.Fingerprint != sdpFingerprint(sdp) {
+		t.Errorf("Unexpected session metadata %+v", d)
+	}
+}
+
+func TestPollClipboard(t *testing.T) {
+	host := &App{ctx: testContext(), configDir: t.TempDir(), settings: &Settings{ICEServers: []string{DefaultICEServer}}}
+	joiner := &App{ctx: testContext(), configDir: t.TempDir(), settings: &Settings{JoinerPort: freePort(t), ICEServers: []string{DefaultICEServer}}}
+	t.Cleanup(func() {
+		host.shutdown(context.Background())
+		joiner.shutdown(context.Background())
+	})
+
+	startTestTarget(t, host)
+	offer, err := host.CreateOffer()
+	if err != nil {
+		t.Fatalf("CreateOffer failed: %v", err)
+	}
+	clipboard := offer
+	host.readClipboard = func() (string, error) { return clipboard, nil }
+	joiner.readClipboard = host.readClipboard
+
+	// The host copied its own offer
+	var last string
+	if _, ok := host.pollClipboard(&last); ok {
+		t.Error("Expected our own offer to be ignored")
+	}
+
+	last = ""
+	d, ok := joiner.pollClipboard(&last)
+	if !ok || d.Kind != TokenKindOffer || d.Token != offer {
+		t.Fatalf("Expected the joiner to detect the offer, got %+v %v", d, ok)
+	}
+	if _, ok := joiner.pollClipboard(&last); ok {
+		t.Error("Expected an unchanged clipboard not to be detected twice")
+	}
+
+	answer, err := joiner.AcceptOffer(d.Token)
+	if err != nil {
+		t.Fatalf("AcceptOffer failed: %v", err)
+	}
+	clipboard = answer
+	d, ok = host.pollClipboard(&last)
+	if !ok || d.Kind != TokenKindAnswer || d.Role != ProfileRoleHost {
+		t.Fatalf("Expected the host to detect the answer, got %+v %v", d, ok)
+	}
+	if err := host.AcceptAnswer(d.Token); err != nil {
+		t.Fatalf("AcceptAnswer failed: %v", err)
+	}
+
+	// With the answer applied there is nothing left to accept
+	last = ""
+	if _, ok := host.pollClipboard(&last); ok {
+		t.Error("Expected an answer not to be offered once connected")
+	}
+}
+
+func TestSetWatchClipboard(t *testing.T) {
+	settings := DefaultSettings()
+	settings.DisplayName = "Alex"
+	app := &App{ctx: testContext(), configDir: t.TempDir(), settings: &settings}
+	app.readClipboard = func() (string, error) { return "", nil }
+	t.Cleanup(app.StopClipboardWatch)
+
+	if err := app.SetWatchClipboard(true); err != nil {
+		t.Fatalf("SetWatchClipboard failed: %v", err)
+	}
+	app.clipboardMu.Lock()
+	watching := app.clipboardStop != nil
+	app.clipboardMu.Unlock()
+	if !watching {
+		t.Error("Expected the watcher to start")
+	}
+
+	// Only the one setting changes, and it survives a restart
+	s, err := loadSettingsFile(filepath.Join(app.configDir, SettingsFileName))
+	if err != nil {
+		t.Fatalf("loadSettingsFile failed: %v", err)
+	}
+	if !s.WatchClipboard || s.DisplayName != "Alex" {
+		t.Errorf("Expected watchClipboard saved next to the display name, got %+v", s)
+	}
+
+	if err := app.SetWatchClipboard(false); err != nil {
+		t.Fatalf("SetWatchClipboard failed: %v", err)
+	}
+	app.clipboardMu.Lock()
+	watching = app.clipboardStop != nil
+	app.clipboardMu.Unlock()
+	if watching || app.GetSettings().WatchClipboard {
+		t.Error("Expected the watcher to stop and the setting to clear")
+	}
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+)
+
+const ControlChannelLabel = "control"
+
+// ControlProtocolVersion is the newest protocol this build speaks. Peers
+// agree on a version in their hellos and stamp it on every message. Bump it
+// when a message changes meaning; new message types and fields do not need a bump.
+const ControlProtocolVersion = 1
+
+const (
+	ControlPingInterval = 5 * time.Second
+	ControlPeerTimeout  = 15 * time.Second
+
+	// How long shutdown waits for the goodbye to leave the send buffer
+	controlGoodbyeFlush = 500 * time.Millisecond
+)
+
+const (
+	controlTypeHello   = "hello"
+	controlTypePortMap = "port-map"
+	controlTypeChat    = "chat"
+	controlTypePing    = "ping"
+	controlTypePong    = "pong"
+	controlTypeStatus  = "status"
+	controlTypeGoodbye = "goodbye"
+
+	controlTypeRCON       = "rcon"
+	controlTypeRCONResult = "rcon-result"
+)
+
+// Features this build understands, announced in the hello
+var controlFeatures = []string{"port-map", "udp", "chat", "status", "rcon", "reconnect"}
+
+// controlMessage is a JSON message on the control channel. Only the fields
+// relevant to Type are set; receivers ignore types they do not know.
+type controlMessage struct {
+	Version     int           `json:"v"`
+	Type        string        `json:"type"`
+	AppVersion  string        `json:"appVersion,omitempty"`
+	Name        string        `json:"name,omitempty"`
+	Protocol    int           `json:"protocol,omitempty"`
+	MinProtocol int           `json:"minProtocol,omitempty"`
+	Features    []string      `json:"features,omitempty"`
+	Mappings    []PortMapping `json:"mappings,omitempty"`
+	Text        string        `json:"text,omitempty"`
+	SentAt      int64         `json:"sentAt,omitempty"`
+	Nonce       int64         `json:"nonce,omitempty"`
+	Status      *statusUpdate `json:"status,omitempty"`
+	Reason      string        `json:"reason,omitempty"`
+	Key         string        `json:"key,omitempty"`
+}
+
+// controlSession is one end of the control channel
+type controlSession struct {
+	app  *App
+	dc   *webrtc.DataChannel
+	host bool
+	done chan struct{}
+	once sync.Once
+
+	helloOnce sync.Once
+	helloErr  error
+	// setupOnce runs the per-peer setup for the first agreed hello only
+	setupOnce sync.Once
+
+	mu           sync.Mutex
+	protocol     int // negotiated version, 0 until both hellos are in
+	peerFeatures []string
+	peerName     string
+	lastPong     time.Time
+	rtt          time.Duration
+}
+
+// openControlSession attaches the control protocol to dc. The host creates
+// the channel; the joiner receives it through OnDataChannel.
+func (a *App) openControlSession(dc *webrtc.DataChannel, host bool) *controlSession {
+	s := &controlSession{app: a, dc: dc, host: host, done: make(chan struct{})}
+
+	dc.OnOpen(func() {
+		a.controlMu.Lock()
+		a.control = s
+		a.controlMu.Unlock()
+
+		s.mu.Lock()
+		s.lastPong = time.Now()
+		s.mu.Unlock()
+
+		if err := s.sayHello(); err != nil {
+			a.safeEventEmit("log", fmt.Sprintf("Failed to open control channel: %v", err))
+			return
+		}
+		go s.keepalive()
+	})
+
+	dc.OnMessage(func(msg webrtc.DataChannelMessage) {
+		var m controlMessage
+		if err := json.Unmarshal(msg.Data, &m); err != nil {
+			return
+		}
+		s.handle(m)
+	})
+
+	dc.OnClose(func() {
+		s.once.Do(func() { close(s.done) })
+		a.controlMu.Lock()
+		if a.control == s {
+			a.control = nil
+		}
+		a.controlMu.Unlock()
+		if !host {
+			a.stopMappingListeners()
+		}
+	})
+
+	return s
+}
+
+// sayHello sends our hello exactly once. The peer's hello can be handled
+// before our OnOpen runs, and ours must still go out before anything else.
+func (s *controlSession) sayHello() error {
+	s.helloOnce.Do(func() {
+		hello := localHello()
+		hello.Name = s.app.GetSettings().DisplayName
+		s.helloErr = s.send(hello)
+	})
+	return s.helloErr
+}
+
+func (s *controlSession) send(msg controlMessage) error {
+	msg.Version = s.negotiated()
+	if msg.Version == 0 {
+		msg.Version = ControlProtocolVersion
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return s.dc.SendText(string(data))
+}
+
+func (s *controlSession) handle(m controlMessage) {
+	a := s.app
+	if m.Type == controlTypeHello {
+		s.handleHello(m)
+		return
+	}
+	// Nothing else is meaningful before the versions are agreed, including
+	// the goodbye a mismatched peer sends after reporting its own error
+	if s.negotiated() == 0 {
+		return
+	}
+
+	switch m.Type {
+	case controlTypePortMap:
+		if !s.host {
+			a.applyPortMappings(m.Mappings)
+		}
+	case controlTypeChat:
+		a.receiveChat(s.peerDisplayName(), m.Text, m.SentAt)
+	case controlTypePing:
+		s.send(controlMessage{Type: controlTypePong, Nonce: m.Nonce})
+	case controlTypePong:
+		s.mu.Lock()
+		s.lastPong = time.Now()
+		s.rtt = time.Since(time.Unix(0, m.Nonce))
+		s.mu.Unlock()
+	case controlTypeStatus:
+		if !s.host && m.Status != nil {
+			a.storeStatus(*m.Status)
+		}
+	case controlTypeRCON:
+		if s.host {
+			go a.serveRemoteRCON(s, m)
+		}
+	case controlTypeRCONResult:
+		if !s.host {
+			a.deliverRCONResult(m)
+		}
+	case controlTypeReconnectKey:
+		if !s.host {
+			s.storeReconnectKey(m.Key)
+		}
+	case controlTypeGoodbye:
+		reason := m.Reason
+		if reason == "" {
+			reason = "Peer closed the tunnel"
+		}
+		a.setSessionPeerReason(reason)
+		a.safeEventEmit("peer-goodbye", reason)
+		a.safeEventEmit("status-change", "disconnected")
+		a.safeEventEmit("log", fmt.Sprintf("Peer disconnected: %s", reason))
+		if pc := a.peerConnection; pc != nil {
+			go pc.Close()
+		}
+	}
+}
+
+// handleHello agrees on a protocol version with the peer, or reports a
+// mismatch and closes the tunnel. Host-only session setup starts here.
+func (s *controlSession) handleHello(m controlMessage) {
+	a := s.app
+	if err := s.sayHello(); err != nil {
+		return
+	}
+	protocol, features, err := negotiateHello(localHello(), m)
+	if err != nil {
+		var mismatch *VersionMismatch
+		if errors.As(err, &mismatch) {
+			a.safeEventEmit("version-mismatch", *mismatch)
+		}
+		a.safeEventEmit("status-change", "error")
+		a.safeEventEmit("log", err.Error())
+		s.send(controlMessage{Type: controlTypeGoodbye, Reason: err.Error()})
+		if pc := a.peerConnection; pc != nil {
+			go pc.Close()
+		}
+		return
+	}
+
+	s.mu.Lock()
+	s.protocol = protocol
+	s.peerFeatures = features
+	s.peerName = truncateRunes(strings.TrimSpace(m.Name), maxDisplayNameLength)
+	s.mu.Unlock()
+	peer := "Peer"
+	if name := s.peerDisplayName(); name != "" {
+		peer = name
+	}
+	s.setupOnce.Do(func() {
+		a.safeEventEmit("log", fmt.Sprintf("%s runs minecraft-tunnel %s (protocol %d)", peer, m.AppVersion, protocol))
+		s.rememberPeer()
+
+		if s.host {
+			if err := s.send(controlMessage{Type: controlTypePortMap, Mappings: a.peerPortMappings()}); err != nil {
+				a.safeEventEmit("log", fmt.Sprintf("Failed to send port mappings: %v", err))
+			}
+			go a.startStatusBroadcaster(s, s.done)
+		}
+	})
+}
+
+// peerDisplayName is the name the peer set in its settings, if any
+func (s *controlSession) peerDisplayName() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.peerName
+}
+
+func (s *controlSession) negotiated() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.protocol
+}
+
+// hasPeerFeature reports whether both peers announced feature
+func (s *controlSession) hasPeerFeature(feature string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, f := range s.peerFeatures {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// keepalive pings the peer every ControlPingInterval and logs once when it
+// has not answered for ControlPeerTimeout
+func (s *controlSession) keepalive() {
+	ticker := time.NewTicker(ControlPingInterval)
+	defer ticker.Stop()
+
+	warned := false
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.send(controlMessage{Type: controlTypePing, Nonce: time.Now().UnixNano()}); err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		silent := time.Since(s.lastPong) > ControlPeerTimeout
+		s.mu.Unlock()
+		if silent && !warned {
+			s.app.safeEventEmit("log", "Peer is not responding")
+		}
+		warned = silent
+	}
+}
+
+// sayGoodbye tells the peer we are leaving and gives the message a moment to
+// be sent before the peer connection is closed
+func (a *App) sayGoodbye(reason string) {
+	a.controlMu.Lock()
+	s := a.control
+	a.controlMu.Unlock()
+	if s == nil {
+		return
+	}
+	if err := s.send(controlMessage{Type: controlTypeGoodbye, Reason: reason}); err != nil {
+		return
+	}
+	for deadline := time.Now().Add(controlGoodbyeFlush); time.Now().Before(deadline) && s.dc.BufferedAmount() > 0; {
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+)
+
+// waitFor polls cond until it holds or the timeout expires
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) bool {
+	t.Helper()
+	for deadline := time.Now().Add(timeout); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		if cond() {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *App) currentControl() *controlSession {
+	a.controlMu.Lock()
+	defer a.controlMu.Unlock()
+	return a.control
+}
+
+func TestControlMessageCarriesVersion(t *testing.T) {
+	sent, _ := json.Marshal(controlMessage{Version: ControlProtocolVersion, Type: controlTypePing, Nonce: 7})
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(sent, &fields); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if fields["v"] != float64(ControlProtocolVersion) || fields["type"] != "ping" {
+		t.Fatalf("Expected version and type, got %s", sent)
+	}
+	if _, ok := fields["mappings"]; ok {
+		t.Errorf("Expected unset fields to be omitted, got %s", sent)
+	}
+}
+
+func TestControlSessionNegotiatesHello(t *testing.T) {
+	host := &App{ctx: testContext()}
+	joiner := &App{ctx: testContext()}
+	connectTestApps(t, host, joiner)
+
+	ok := waitFor(t, 5*time.Second, func() bool {
+		h, j := host.currentControl(), joiner.currentControl()
+		return h != nil && j != nil && h.hasPeerFeature("chat") && j.hasPeerFeature("port-map")
+	})
+	if !ok {
+		t.Fatal("Expected both peers to learn each other's features")
+	}
+}
+
+func TestHostStatusReachesJoinerOverControl(t *testing.T) {
+	server := startFakeMinecraftServer(t, testStatusJSON)
+	host := &App{ctx: testContext()}
+	if err := host.SetTargetAddress(server); err != nil {
+		t.Fatalf("SetTargetAddress failed: %v", err)
+	}
+	joiner := &App{ctx: testContext()}
+	connectTestApps(t, host, joiner)
+
+	ok := waitFor(t, 5*time.Second, func() bool {
+		joiner.statusMu.Lock()
+		defer joiner.statusMu.Unlock()
+		return joiner.status != nil && joiner.status.update.Online
+	})
+	if !ok {
+		t.Fatal("Expected joiner to cache the host's server status")
+	}
+}
+
+func TestControlPongUpdatesRTT(t *testing.T) {
+	s := &controlSession{app: &App{ctx: testContext()}, host: true, protocol: ControlProtocolVersion}
+	s.handle(controlMessage{Type: controlTypePong, Nonce: time.Now().Add(-30 * time.Millisecond).UnixNano()})
+
+	if s.rtt < 30*time.Millisecond || s.lastPong.IsZero() {
+		t.Fatalf("Expected RTT of at least 30ms, got %v", s.rtt)
+	}
+}
+
+func TestControlGoodbyeClosesPeerConnection(t *testing.T) {
+	host := &App{ctx: testContext()}
+	joiner := &App{ctx: testContext()}
+	connectTestApps(t, host, joiner)
+
+	if !waitFor(t, 5*time.Second, func() bool {
+		h, j := host.currentControl(), joiner.currentControl()
+		return h != nil && j != nil && h.negotiated() != 0 && j.negotiated() != 0
+	}) {
+		t.Fatal("Expected the control sessions to negotiate")
+	}
+	if err := host.currentControl().send(controlMessage{Type: controlTypeGoodbye, Reason: "Host stopped"}); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+
+	if !waitFor(t, 5*time.Second, func() bool {
+		return joiner.peerConnection.ConnectionState() == webrtc.PeerConnectionStateClosed
+	}) {
+		t.Fatal("Expected the joiner to close its peer connection on goodbye")
+	}
+}
@@ -14,6 +14,7 @@ func TestCreateOfferWithRealContext(t *testing.T) {
 	t.Logf("Testing CreateOffer with real context (not test mode)")
 	t.Logf("testModeKey value: %v", ctx.Value(testModeKey))
 
+	startTestTarget(t, app)
 	token, err := app.CreateOffer()
 	if err != nil {
 		t.Fatalf("CreateOffer failed with real context: %v", err)
@@ -35,6 +36,7 @@ func TestCreateOfferWithRealContext(t *testing.T) {
 func TestAcceptOfferWithRealContext(t *testing.T) {
 	ctx := context.Background()
 	hostApp := &App{ctx: ctx}
+	startTestTarget(t, hostApp)
 
 	// Create offer with real context
 	offerToken, err := hostApp.CreateOffer()
@@ -68,6 +70,7 @@ func TestAcceptOfferWithRealContext(t *testing.T) {
 func TestAcceptAnswerWithRealContext(t *testing.T) {
 	ctx := context.Background()
 	hostApp := &App{ctx: ctx}
+	startTestTarget(t, hostApp)
 
 	// Create offer
 	offerToken, err := hostApp.CreateOffer()
@@ -1,5 +1,6 @@
 import { Router } from "@/components/Router";
 import { Toast } from "@/components/custom/toast";
+import { TokenDetected } from "@/components/custom/token-detected";
 import "@/style.css";
 
 function App() {
@@ -7,6 +8,7 @@ function App() {
     <>
       <Router />
       <Toast />
+      <TokenDetected />
     </>
   );
 }
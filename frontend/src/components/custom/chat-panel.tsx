@@ -0,0 +1,87 @@
+import React, { useEffect, useRef, useState } from "react";
+import { MessageSquare, Send } from "lucide-react";
+import { Button } from "@/components/ui/button";
+import { Input } from "@/components/ui/input";
+import { Label } from "@/components/ui/label";
+import { ScrollArea } from "@/components/ui/scroll-area";
+import { EventsOn, EventsOff } from "../../../wailsjs/runtime/runtime";
+import { GetChatHistory, SendChatMessage } from "../../../wailsjs/go/main/App";
+import { main } from "../../../wailsjs/go/models";
+import { useToastStore } from "@/lib/toastStore";
+
+export const ChatPanel: React.FC = () => {
+  const [messages, setMessages] = useState<main.ChatMessage[]>([]);
+  const [draft, setDraft] = useState("");
+  const scrollRef = useRef<HTMLDivElement>(null);
+
+  useEffect(() => {
+    GetChatHistory().then((history) => setMessages(history ?? []));
+    EventsOn("chat-message", (msg: main.ChatMessage) =>
+      setMessages((current) => [...current, msg]),
+    );
+    return () => {
+      EventsOff("chat-message");
+    };
+  }, []);
+
+  useEffect(() => {
+    scrollRef.current?.scrollIntoView({ behavior: "smooth" });
+  }, [messages]);
+
+  const send = async (e: React.FormEvent) => {
+    e.preventDefault();
+    if (!draft.trim()) return;
+    try {
+      const msg = await SendChatMessage(draft);
+      setMessages((current) => [...current, msg]);
+      setDraft("");
+    } catch (error) {
+      useToastStore.getState().addToast({
+        title: "Message not sent",
+        description: String(error),
+        variant: "destructive",
+      });
+    }
+  };
+
+  return (
+    <div className="space-y-2">
+      <Label className="text-sm font-medium flex items-center gap-2">
+        <MessageSquare className="w-4 h-4" />
+        Chat
+      </Label>
+      <div className="rounded-lg border p-4 shadow-inner">
+        <ScrollArea className="h-32 w-full pr-4">
+          <div className="flex flex-col gap-1 text-sm">
+            {messages.length === 0 && (
+              <div className="italic select-none py-6 text-center text-xs">
+                No messages yet
+              </div>
+            )}
+            {messages.map((msg, i) => (
+              <div key={i} className={msg.fromPeer ? "" : "text-right"}>
+                <span className="mr-2 text-xs text-slate-500">
+                  {new Date(msg.sentAt).toLocaleTimeString([], { hour12: false })}
+                </span>
+                <span className="font-medium">{msg.fromPeer ? msg.from || "Friend" : "You"}:</span>{" "}
+                {msg.text}
+              </div>
+            ))}
+            <div ref={scrollRef} />
+          </div>
+        </ScrollArea>
+      </div>
+      <form onSubmit={send} className="flex gap-2">
+        <Input
+          value={draft}
+          onChange={(e) => setDraft(e.target.value)}
+          maxLength={500}
+          placeholder="Say something..."
+        />
+        <Button type="submit" size="sm" disabled={!draft.trim()}>
+          <Send className="w-4 h-4" />
+        </Button>
+      </form>
+    </div>
+  );
+};
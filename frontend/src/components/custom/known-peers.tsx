@@ -0,0 +1,101 @@
+import React, { useEffect, useState } from "react";
+import { RotateCw, Terminal, Users, X } from "lucide-react";
+import { Button } from "@/components/ui/button";
+import { Label } from "@/components/ui/label";
+import { ForgetPeer, ListKnownPeers, ReconnectPeer, SetPeerRCONAccess } from "../../../wailsjs/go/main/App";
+import { main } from "../../../wailsjs/go/models";
+import { useToastStore } from "@/lib/toastStore";
+
+interface KnownPeersProps {
+  // Only peers we had this role with are listed
+  role: "host" | "join";
+}
+
+export const KnownPeers: React.FC<KnownPeersProps> = ({ role }) => {
+  const [peers, setPeers] = useState<main.KnownPeer[]>([]);
+  const [busy, setBusy] = useState<string | null>(null);
+
+  const refresh = () =>
+    ListKnownPeers()
+      .then((all) => setPeers((all ?? []).filter((p) => p.role === role && p.reconnectKey)))
+      .catch(() => setPeers([]));
+
+  useEffect(() => {
+    refresh();
+  }, [role]);
+
+  const reconnect = async (peer: main.KnownPeer) => {
+    setBusy(peer.fingerprint);
+    try {
+      await ReconnectPeer(peer.fingerprint);
+    } catch (error) {
+      useToastStore.getState().addToast({
+        title: `Could not reconnect to ${peer.displayName || "peer"}`,
+        description: String(error),
+        variant: "destructive",
+      });
+    } finally {
+      setBusy(null);
+    }
+  };
+
+  const forget = async (peer: main.KnownPeer) => {
+    await ForgetPeer(peer.fingerprint);
+    refresh();
+  };
+
+  // Host only: let this friend run server commands when they join
+  const toggleRCON = async (peer: main.KnownPeer) => {
+    try {
+      await SetPeerRCONAccess(peer.fingerprint, !peer.allowRcon);
+      refresh();
+    } catch (error) {
+      useToastStore.getState().addToast({
+        title: "Could not change RCON access",
+        description: String(error),
+        variant: "destructive",
+      });
+    }
+  };
+
+  if (peers.length === 0) return null;
+
+  return (
+    <div className="space-y-2">
+      <Label className="text-sm font-medium flex items-center gap-2">
+        <Users className="w-4 h-4" />
+        Known friends
+      </Label>
+      {peers.map((peer) => (
+        <div key={peer.fingerprint} className="flex items-center gap-2">
+          <Button
+            variant="outline"
+            size="sm"
+            className="flex-1 justify-start"
+            disabled={busy !== null}
+            onClick={() => reconnect(peer)}
+          >
+            <RotateCw className={`w-4 h-4 mr-2 ${busy === peer.fingerprint ? "animate-spin" : ""}`} />
+            Reconnect to {peer.displayName || "peer"}
+          </Button>
+          <span className="text-xs text-slate-500">
+            {new Date(peer.lastSeen).toLocaleDateString()}
+          </span>
+          {role === "host" && (
+            <Button
+              variant={peer.allowRcon ? "default" : "ghost"}
+              size="sm"
+              onClick={() => toggleRCON(peer)}
+              aria-label={peer.allowRcon ? "Revoke RCON access" : "Grant RCON access"}
+            >
+              <Terminal className="w-4 h-4" />
+            </Button>
+          )}
+          <Button variant="ghost" size="sm" onClick={() => forget(peer)} aria-label="Forget">
+            <X className="w-4 h-4" />
+          </Button>
+        </div>
+      ))}
+    </div>
+  );
+};
@@ -0,0 +1,99 @@
+import React, { useState } from "react";
+import { Radio, Search, Wifi } from "lucide-react";
+import { Button } from "@/components/ui/button";
+import { Label } from "@/components/ui/label";
+import {
+  BrowseLANTunnels,
+  JoinLANTunnel,
+  StartLANSignaling,
+  StopLANSignaling,
+} from "../../../wailsjs/go/main/App";
+import { main } from "../../../wailsjs/go/models";
+import { useToastStore } from "@/lib/toastStore";
+
+const toastError = (title: string, error: unknown) =>
+  useToastStore.getState().addToast({
+    title,
+    description: String(error),
+    variant: "destructive",
+  });
+
+// Host side: advertise over mDNS so joiners on the same network need no tokens
+export const LANAdvertiseButton: React.FC = () => {
+  const [advertising, setAdvertising] = useState(false);
+
+  const toggle = async () => {
+    try {
+      if (advertising) {
+        await StopLANSignaling();
+      } else {
+        await StartLANSignaling();
+      }
+      setAdvertising(!advertising);
+    } catch (error) {
+      toastError("Local network sharing failed", error);
+    }
+  };
+
+  return (
+    <Button variant="outline" size="sm" onClick={toggle}>
+      <Radio className={`w-4 h-4 mr-2 ${advertising ? "animate-pulse" : ""}`} />
+      {advertising ? "Stop sharing on local network" : "Share on local network"}
+    </Button>
+  );
+};
+
+// Join side: browse for hosts on the same network and connect in one click
+export const LANTunnels: React.FC = () => {
+  const [tunnels, setTunnels] = useState<main.LANTunnel[]>([]);
+  const [searching, setSearching] = useState(false);
+  const [joining, setJoining] = useState<string | null>(null);
+
+  const browse = async () => {
+    setSearching(true);
+    try {
+      setTunnels((await BrowseLANTunnels()) ?? []);
+    } catch (error) {
+      toastError("Local network search failed", error);
+    } finally {
+      setSearching(false);
+    }
+  };
+
+  const join = async (tunnel: main.LANTunnel) => {
+    setJoining(tunnel.address);
+    try {
+      await JoinLANTunnel(tunnel);
+    } catch (error) {
+      toastError(`Could not join ${tunnel.name || tunnel.instance}`, error);
+    } finally {
+      setJoining(null);
+    }
+  };
+
+  return (
+    <div className="space-y-2">
+      <Label className="text-sm font-medium flex items-center gap-2">
+        <Wifi className="w-4 h-4" />
+        On your network
+      </Label>
+      {tunnels.map((tunnel) => (
+        <Button
+          key={tunnel.address}
+          variant="outline"
+          size="sm"
+          className="w-full justify-start"
+          disabled={joining !== null}
+          onClick={() => join(tunnel)}
+        >
+          Join {tunnel.name || tunnel.instance}
+          <span className="ml-auto text-xs text-slate-500">{tunnel.address}</span>
+        </Button>
+      ))}
+      <Button variant="outline" size="sm" onClick={browse} disabled={searching}>
+        <Search className={`w-4 h-4 mr-2 ${searching ? "animate-pulse" : ""}`} />
+        {searching ? "Searching..." : "Find hosts on local network"}
+      </Button>
+    </div>
+  );
+};
@@ -0,0 +1,147 @@
+import React, { useEffect, useRef, useState } from "react";
+import { Play, Square, SquareTerminal } from "lucide-react";
+import { Button } from "@/components/ui/button";
+import { Input } from "@/components/ui/input";
+import { Label } from "@/components/ui/label";
+import { ScrollArea } from "@/components/ui/scroll-area";
+import { EventsOn, EventsOff } from "../../../wailsjs/runtime/runtime";
+import {
+  GetServerState,
+  GetSettings,
+  SendServerCommand,
+  StartServer,
+  StopServer,
+} from "../../../wailsjs/go/main/App";
+import { main } from "../../../wailsjs/go/models";
+import { useToastStore } from "@/lib/toastStore";
+
+// Console lines kept on screen; older ones are dropped
+const maxConsoleLines = 500;
+
+// Host side: start the Minecraft server from its jar, watch its console and
+// type commands into it. The last config that started is saved by StartServer.
+export const ServerConsole: React.FC = () => {
+  const [state, setState] = useState("stopped");
+  const [config, setConfig] = useState<main.ServerLaunchConfig>(main.ServerLaunchConfig.createFrom({}));
+  const [lines, setLines] = useState<string[]>([]);
+  const [command, setCommand] = useState("");
+  const scrollRef = useRef<HTMLDivElement>(null);
+
+  useEffect(() => {
+    GetServerState().then(setState);
+    GetSettings()
+      .then((settings) => settings.server && setConfig(main.ServerLaunchConfig.createFrom(settings.server)))
+      .catch(() => {});
+    EventsOn("server-state", setState);
+    EventsOn("server-console", (line: string) =>
+      setLines((current) => [...current, line].slice(-maxConsoleLines)),
+    );
+    return () => {
+      EventsOff("server-state");
+      EventsOff("server-console");
+    };
+  }, []);
+
+  useEffect(() => {
+    scrollRef.current?.scrollIntoView({ behavior: "smooth" });
+  }, [lines]);
+
+  const fail = (title: string, error: unknown) =>
+    useToastStore.getState().addToast({ title, description: String(error), variant: "destructive" });
+
+  const start = async () => {
+    setLines([]);
+    try {
+      await StartServer(config);
+    } catch (error) {
+      fail("Could not start the server", error);
+    }
+  };
+
+  const stop = async () => {
+    try {
+      await StopServer();
+    } catch (error) {
+      fail("Server did not stop cleanly", error);
+    }
+  };
+
+  const send = async (e: React.FormEvent) => {
+    e.preventDefault();
+    if (!command.trim()) return;
+    try {
+      await SendServerCommand(command);
+      setCommand("");
+    } catch (error) {
+      fail("Command not sent", error);
+    }
+  };
+
+  const stopped = state === "stopped";
+
+  return (
+    <div className="space-y-2">
+      <div className="flex items-center justify-between">
+        <Label className="text-sm font-medium flex items-center gap-2">
+          <SquareTerminal className="w-4 h-4" />
+          Server Console
+        </Label>
+        <span className="text-xs capitalize">{state}</span>
+      </div>
+      <div className="flex gap-2">
+        <Input
+          placeholder="/path/to/server.jar"
+          value={config.jarPath ?? ""}
+          onChange={(e) => setConfig(main.ServerLaunchConfig.createFrom({ ...config, jarPath: e.target.value }))}
+          disabled={!stopped}
+          className="font-mono text-xs flex-1"
+        />
+        <Input
+          placeholder="Memory, e.g. 2G"
+          value={config.maxMemory ?? ""}
+          onChange={(e) => setConfig(main.ServerLaunchConfig.createFrom({ ...config, maxMemory: e.target.value }))}
+          disabled={!stopped}
+          className="font-mono text-xs w-32"
+        />
+        {stopped ? (
+          <Button variant="outline" onClick={start} disabled={!config.jarPath}>
+            <Play className="w-4 h-4 mr-2" />
+            Start
+          </Button>
+        ) : (
+          <Button variant="outline" onClick={stop} disabled={state === "stopping"}>
+            <Square className="w-4 h-4 mr-2" />
+            Stop
+          </Button>
+        )}
+      </div>
+      {lines.length > 0 && (
+        <div className="rounded-lg border p-4 shadow-inner">
+          <ScrollArea className="h-40 w-full pr-4">
+            <div className="flex flex-col gap-1 font-mono text-xs">
+              {lines.map((line, i) => (
+                <div key={i} className="break-all">
+                  {line}
+                </div>
+              ))}
+              <div ref={scrollRef} />
+            </div>
+          </ScrollArea>
+        </div>
+      )}
+      {!stopped && (
+        <form onSubmit={send} className="flex gap-2">
+          <Input
+            value={command}
+            onChange={(e) => setCommand(e.target.value)}
+            placeholder="Server command, e.g. list"
+            className="font-mono text-xs"
+          />
+          <Button type="submit" variant="outline">
+            Send
+          </Button>
+        </form>
+      )}
+    </div>
+  );
+};
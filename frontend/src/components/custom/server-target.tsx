@@ -0,0 +1,139 @@
+import React, { useEffect, useState } from "react";
+import { FolderOpen, Gamepad2, X } from "lucide-react";
+import { Button } from "@/components/ui/button";
+import { Label } from "@/components/ui/label";
+import { EventsOn, EventsOff } from "../../../wailsjs/runtime/runtime";
+import {
+  ChooseServerDirectory,
+  ClearServerDirectory,
+  GetServerProperties,
+  ListLANWorlds,
+  SelectLANWorld,
+  StartLANDiscovery,
+  StopLANDiscovery,
+} from "../../../wailsjs/go/main/App";
+import { main } from "../../../wailsjs/go/models";
+import { useTunnelStore } from "@/lib/tunnelStore";
+import { useToastStore } from "@/lib/toastStore";
+
+// Host side: worlds opened to LAN on this network, picked as the target in one click
+export const LANWorldPicker: React.FC = () => {
+  const [worlds, setWorlds] = useState<main.LANWorld[]>([]);
+  const { mcServerAddress, targetSource, selectTarget } = useTunnelStore();
+
+  useEffect(() => {
+    StartLANDiscovery()
+      .then(() => ListLANWorlds())
+      .then((found) => setWorlds(found ?? []))
+      .catch(() => setWorlds([]));
+    EventsOn("lan-world-discovered", (world: main.LANWorld) =>
+      setWorlds((current) => [...current.filter((w) => w.address !== world.address), world]),
+    );
+    return () => {
+      EventsOff("lan-world-discovered");
+      StopLANDiscovery();
+    };
+  }, []);
+
+  const select = async (world: main.LANWorld) => {
+    try {
+      await SelectLANWorld(world.address);
+      selectTarget(world.address, "lan");
+    } catch (error) {
+      useToastStore.getState().addToast({
+        title: "Could not use this world",
+        description: String(error),
+        variant: "destructive",
+      });
+    }
+  };
+
+  if (worlds.length === 0) return null;
+
+  return (
+    <div className="space-y-2">
+      <Label className="text-sm font-medium flex items-center gap-2">
+        <Gamepad2 className="w-4 h-4" />
+        Worlds open to LAN
+      </Label>
+      {worlds.map((world) => {
+        const selected = targetSource === "lan" && mcServerAddress === world.address;
+        return (
+          <Button
+            key={world.address}
+            variant={selected ? "default" : "outline"}
+            size="sm"
+            className="w-full justify-start"
+            onClick={() => select(world)}
+          >
+            {world.motd || "LAN world"}
+            <span className="ml-auto text-xs text-slate-500">{world.address}</span>
+          </Button>
+        );
+      })}
+    </div>
+  );
+};
+
+// Host side: follow a server folder's server.properties. While it is used,
+// the address field shows the address derived from it and cannot be edited.
+export const ServerDirectoryPicker: React.FC<{ disabled?: boolean }> = ({ disabled }) => {
+  const [directory, setDirectory] = useState("");
+  const { targetSource, selectTarget, setMcServerAddress, mcServerAddress } = useTunnelStore();
+
+  useEffect(() => {
+    if (useTunnelStore.getState().targetSource === "server-directory") {
+      GetServerProperties()
+        .then((props) => setDirectory(props.directory))
+        .catch(() => setDirectory(""));
+    }
+    EventsOn("server-properties", (props: main.ServerProperties) => {
+      if (useTunnelStore.getState().targetSource === "server-directory") {
+        selectTarget(props.target, "server-directory");
+      }
+    });
+    return () => {
+      EventsOff("server-properties");
+    };
+  }, [selectTarget]);
+
+  const choose = async () => {
+    try {
+      const props = await ChooseServerDirectory();
+      if (!props.directory) return;
+      setDirectory(props.directory);
+      selectTarget(props.target, "server-directory");
+    } catch (error) {
+      useToastStore.getState().addToast({
+        title: "Could not read server.properties",
+        description: String(error),
+        variant: "destructive",
+      });
+    }
+  };
+
+  const clear = async () => {
+    await ClearServerDirectory();
+    setDirectory("");
+    setMcServerAddress(mcServerAddress);
+  };
+
+  if (targetSource === "server-directory") {
+    return (
+      <div className="flex items-center gap-2 text-xs text-slate-500">
+        <FolderOpen className="w-4 h-4" />
+        <span className="flex-1 truncate">Following {directory}</span>
+        <Button variant="ghost" size="sm" onClick={clear} disabled={disabled} aria-label="Stop following">
+          <X className="w-4 h-4" />
+        </Button>
+      </div>
+    );
+  }
+
+  return (
+    <Button variant="outline" size="sm" onClick={choose} disabled={disabled}>
+      <FolderOpen className="w-4 h-4 mr-2" />
+      Use server folder
+    </Button>
+  );
+};
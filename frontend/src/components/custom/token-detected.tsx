@@ -0,0 +1,81 @@
+import React, { useEffect, useState } from "react";
+import { ClipboardCheck, X } from "lucide-react";
+import { Button } from "@/components/ui/button";
+import { EventsOn, EventsOff } from "../../../wailsjs/runtime/runtime";
+import { GetSettings, SetWatchClipboard } from "../../../wailsjs/go/main/App";
+import { main } from "../../../wailsjs/go/models";
+import { useAppStore } from "@/lib/store";
+import { useTunnelStore } from "@/lib/tunnelStore";
+import { useToastStore } from "@/lib/toastStore";
+
+// Offers to accept an offer or answer the moment it is copied, when
+// clipboard watching is on
+export const TokenDetected: React.FC = () => {
+  const [detected, setDetected] = useState<main.DetectedToken | null>(null);
+
+  useEffect(() => {
+    EventsOn("token-detected", (token: main.DetectedToken) => setDetected(token));
+    return () => {
+      EventsOff("token-detected");
+    };
+  }, []);
+
+  if (!detected) return null;
+
+  const from = detected.peerName || "a friend";
+  const accept = async () => {
+    setDetected(null);
+    if (detected.kind === "offer") {
+      useAppStore.getState().setRoute("/join");
+      await useTunnelStore.getState().acceptOffer(detected.token);
+    } else {
+      await useTunnelStore.getState().acceptAnswer(detected.token);
+    }
+  };
+
+  return (
+    <div className="fixed top-4 right-4 z-50 flex items-center gap-3 rounded-lg border border-slate-200 dark:border-slate-800 bg-white dark:bg-slate-900 p-4 shadow-lg">
+      <ClipboardCheck className="w-4 h-4" />
+      <span className="text-sm">
+        {detected.kind === "offer" ? `Offer from ${from} copied` : `Answer from ${from} copied`}
+      </span>
+      <Button size="sm" onClick={accept}>
+        {detected.kind === "offer" ? "Join" : "Connect"}
+      </Button>
+      <button
+        onClick={() => setDetected(null)}
+        className="text-slate-400 hover:text-slate-600 transition-colors"
+      >
+        <X className="h-4 w-4" />
+      </button>
+    </div>
+  );
+};
+
+export const ClipboardWatchToggle: React.FC = () => {
+  const [watching, setWatching] = useState(false);
+
+  useEffect(() => {
+    GetSettings().then((settings) => setWatching(!!settings?.watchClipboard));
+  }, []);
+
+  const toggle = async () => {
+    try {
+      await SetWatchClipboard(!watching);
+      setWatching(!watching);
+    } catch (error) {
+      useToastStore.getState().addToast({
+        title: "Could not change clipboard watching",
+        description: String(error),
+        variant: "destructive",
+      });
+    }
+  };
+
+  return (
+    <Button variant="ghost" size="sm" onClick={toggle}>
+      <ClipboardCheck className="w-4 h-4 mr-2" />
+      {watching ? "Stop watching clipboard" : "Watch clipboard for tokens"}
+    </Button>
+  );
+};
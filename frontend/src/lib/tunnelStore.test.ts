@@ -1,4 +1,17 @@
+import { vi } from "vitest";
 import { useTunnelStore } from "./tunnelStore";
+import * as App from "../../wailsjs/go/main/App";
+
+vi.mock("../../wailsjs/go/main/App", () => ({
+  CreateOffer: vi.fn().mockResolvedValue("offer-token"),
+  AcceptOffer: vi.fn(),
+  AcceptAnswer: vi.fn(),
+  GetSettings: vi.fn().mockResolvedValue({ targetAddress: "localhost:25565", joinerPort: 42517 }),
+  SetTargetAddress: vi.fn(),
+  StartHostProxy: vi.fn(),
+  StartJoinerProxy: vi.fn(),
+  UpdateSettings: vi.fn(),
+}));
 
 describe("tunnelStore", () => {
   it("should initialize with default state", () => {
@@ -7,4 +20,13 @@ describe("tunnelStore", () => {
     expect(store.offerToken).toBe("");
     expect(store.answerToken).toBe("");
   });
+
+  it("keeps a selected LAN world as the target when generating an offer", async () => {
+    useTunnelStore.getState().selectTarget("192.168.1.20:51234", "lan");
+    await useTunnelStore.getState().generateOffer();
+
+    expect(App.SetTargetAddress).not.toHaveBeenCalled();
+    expect(App.UpdateSettings).not.toHaveBeenCalled();
+    expect(useTunnelStore.getState().offerToken).toBe("offer-token");
+  });
 });
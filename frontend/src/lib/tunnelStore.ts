@@ -3,13 +3,21 @@ import {
   CreateOffer,
   AcceptOffer,
   AcceptAnswer,
+  GetSettings,
+  SetTargetAddress,
   StartHostProxy,
   StartJoinerProxy,
+  UpdateSettings,
 } from "../../wailsjs/go/main/App";
+import { main } from "../../wailsjs/go/models";
 import { useToastStore } from "./toastStore";
 
 type TunnelStatus = "disconnected" | "connecting" | "connected" | "error" | "waiting-for-answer" | "waiting-for-host";
 
+// Where the host's Minecraft address came from. Only a typed address is
+// sent to the backend and saved; the others are already set there.
+export type TargetSource = "manual" | "lan" | "server-directory";
+
 interface LogEntry {
   timestamp: Date;
   message: string;
@@ -22,10 +30,13 @@ interface TunnelState {
   offerToken: string;
   answerToken: string;
   mcServerAddress: string;
+  targetSource: TargetSource;
   proxyPort: string;
 
   // Actions
   setMcServerAddress: (address: string) => void;
+  selectTarget: (address: string, source: TargetSource) => void;
+  loadSettings: () => Promise<void>;
   setProxyPort: (port: string) => void;
   generateOffer: () => Promise<void>;
   acceptOffer: (offer: string) => Promise<void>;
@@ -43,21 +54,45 @@ export const useTunnelStore = create<TunnelState>((set, get) => ({
   offerToken: "",
   answerToken: "",
   mcServerAddress: "localhost:42517",
+  targetSource: "manual",
   proxyPort: "42517",
 
-  setMcServerAddress: (address) => set({ mcServerAddress: address }),
+  setMcServerAddress: (address) => set({ mcServerAddress: address, targetSource: "manual" }),
+  selectTarget: (address, source) => set({ mcServerAddress: address, targetSource: source }),
+
+  loadSettings: async () => {
+    try {
+      const settings = await GetSettings();
+      set({ proxyPort: String(settings.joinerPort) });
+      if (get().targetSource === "manual") {
+        set({ mcServerAddress: settings.targetAddress });
+      }
+    } catch (err) {
+      console.error("[FRONTEND] Failed to load settings:", err);
+    }
+  },
   setProxyPort: (port) => set({ proxyPort: port }),
 
   generateOffer: async () => {
     console.log("[FRONTEND] generateOffer called");
     set({ status: "connecting", logs: [], offerToken: "" });
+    const manual = get().targetSource === "manual";
     try {
+      if (manual) {
+        await SetTargetAddress(get().mcServerAddress);
+      }
       console.log("[FRONTEND] Calling CreateOffer()...");
+      // CreateOffer pings the Minecraft server first and fails while it is down
       const token = await CreateOffer();
       console.log("[FRONTEND] CreateOffer returned, token length:", token?.length);
       console.log("[FRONTEND] Token preview:", token?.substring(0, 50) + "...");
       set({ status: "waiting-for-answer", offerToken: token });
       get().addLog("Offer token generated successfully");
+      // Remember a typed address that works for next time
+      const settings = await GetSettings();
+      if (manual && settings.targetAddress !== get().mcServerAddress) {
+        await UpdateSettings(main.Settings.createFrom({ ...settings, targetAddress: get().mcServerAddress }));
+      }
       console.log("[FRONTEND] State updated to waiting-for-answer");
     } catch (err: any) {
       console.error("[FRONTEND] CreateOffer error:", err);
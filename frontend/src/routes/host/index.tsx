@@ -1,9 +1,15 @@
 import React, { useEffect, useRef } from "react";
 import { useTunnelStore } from "@/lib/tunnelStore";
 import { useAppStore } from "@/lib/store";
+import { useToastStore } from "@/lib/toastStore";
 import { EventsOn, EventsOff } from "../../../wailsjs/runtime/runtime";
 import { TokenCard } from "@/components/custom/token-card";
 import Sigil from "@/components/custom/sigil";
+import { ChatPanel } from "@/components/custom/chat-panel";
+import { KnownPeers } from "@/components/custom/known-peers";
+import { LANAdvertiseButton } from "@/components/custom/lan-tunnels";
+import { LANWorldPicker, ServerDirectoryPicker } from "@/components/custom/server-target";
+import { ServerConsole } from "@/components/custom/server-console";
 
 import { Power, ArrowLeft, Activity, Terminal, Server, RotateCcw } from "lucide-react";
 
@@ -28,7 +34,9 @@ export const HostView = () => {
     logs,
     offerToken,
     mcServerAddress,
+    targetSource,
     setMcServerAddress,
+    loadSettings,
     addLog,
     setStatus,
     generateOffer,
@@ -39,14 +47,36 @@ export const HostView = () => {
 
   const scrollRef = useRef<HTMLDivElement>(null);
 
+  useEffect(() => {
+    loadSettings();
+  }, [loadSettings]);
+
   useEffect(() => {
     EventsOn("log", addLog);
     EventsOn("status-change", (newStatus: string) =>
       setStatus(newStatus as any),
     );
+    EventsOn("nat-warning", (report: { warning: string }) =>
+      useToastStore.getState().addToast({
+        title: "Direct connection unlikely",
+        description: report.warning,
+        variant: "destructive",
+        duration: 10000,
+      }),
+    );
+    EventsOn("version-mismatch", (mismatch: { message: string }) =>
+      useToastStore.getState().addToast({
+        title: "Please update minecraft-tunnel",
+        description: mismatch.message,
+        variant: "destructive",
+        duration: 10000,
+      }),
+    );
     return () => {
       EventsOff("log");
       EventsOff("status-change");
+      EventsOff("nat-warning");
+      EventsOff("version-mismatch");
     };
   }, [addLog, setStatus]);
 
@@ -101,10 +131,13 @@ export const HostView = () => {
               placeholder="localhost:42517"
               value={mcServerAddress}
               onChange={(e) => setMcServerAddress(e.target.value)}
-              disabled={isRunning}
+              disabled={isRunning || targetSource === "server-directory"}
               className="font-mono text-sm"
             />
+            <ServerDirectoryPicker disabled={isRunning} />
           </div>
+          {(status === "disconnected" || status === "error") && <LANWorldPicker />}
+          <ServerConsole />
 
           {/* Offer Token Section */}
           {(status === "waiting-for-answer" || status === "connected") && (
@@ -179,6 +212,11 @@ export const HostView = () => {
               </ScrollArea>
             </div>
           </div>
+
+          {/* Chat */}
+          {status === "connected" && <ChatPanel />}
+          {(status === "disconnected" || status === "error") && <KnownPeers role="host" />}
+          {(status === "disconnected" || status === "error") && <LANAdvertiseButton />}
         </CardContent>
 
         <CardFooter className="flex justify-between pt-2 border-t border-slate-100">
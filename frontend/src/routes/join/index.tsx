@@ -1,9 +1,13 @@
 import React, { useEffect, useRef, useState } from "react";
 import { useAppStore } from "@/lib/store";
 import { useTunnelStore } from "@/lib/tunnelStore";
+import { useToastStore } from "@/lib/toastStore";
 import { EventsOn, EventsOff } from "../../../wailsjs/runtime/runtime";
 import { TokenCard } from "@/components/custom/token-card";
 import Sigil from "@/components/custom/sigil";
+import { ChatPanel } from "@/components/custom/chat-panel";
+import { KnownPeers } from "@/components/custom/known-peers";
+import { LANTunnels } from "@/components/custom/lan-tunnels";
 
 import {
   ArrowLeft,
@@ -42,9 +46,18 @@ export const JoinView = () => {
     EventsOn("status-change", (newStatus: string) =>
       setStatus(newStatus as any),
     );
+    EventsOn("version-mismatch", (mismatch: { message: string }) =>
+      useToastStore.getState().addToast({
+        title: "Please update minecraft-tunnel",
+        description: mismatch.message,
+        variant: "destructive",
+        duration: 10000,
+      }),
+    );
     return () => {
       EventsOff("log");
       EventsOff("status-change");
+      EventsOff("version-mismatch");
     };
   }, [addLog, setStatus]);
 
@@ -198,6 +211,11 @@ export const JoinView = () => {
               </ScrollArea>
             </div>
           </div>
+
+          {/* Chat */}
+          {status === "connected" && <ChatPanel />}
+          {(status === "disconnected" || status === "error") && <KnownPeers role="join" />}
+          {(status === "disconnected" || status === "error") && <LANTunnels />}
         </CardContent>
 
         <CardFooter className="flex justify-between pt-2 border-t border-slate-100">
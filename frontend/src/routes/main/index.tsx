@@ -1,5 +1,6 @@
 import Sigil from "@/components/custom/sigil";
 import { RouteButton } from "@/components/custom/route-button";
+import { ClipboardWatchToggle } from "@/components/custom/token-detected";
 export default function Main() {
   return (
     <div className="flex flex-col gap-2 w-full justify-center items-center">
@@ -16,6 +17,7 @@ export default function Main() {
         <RouteButton route="/join" variant="default">
           Join
         </RouteButton>
+        <ClipboardWatchToggle />
       </div>
     </div>
   );
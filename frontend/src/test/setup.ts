@@ -39,6 +39,32 @@ beforeAll(() => {
         StartJoinerProxy: vi.fn(),
         ExportToFile: vi.fn(),
         ImportFromFile: vi.fn(),
+        GetSettings: vi.fn().mockResolvedValue({}),
+        PingMinecraftServer: vi.fn(),
+        SetTargetAddress: vi.fn(),
+        UpdateSettings: vi.fn(),
+        ListKnownPeers: vi.fn().mockResolvedValue([]),
+        ForgetPeer: vi.fn(),
+        ReconnectPeer: vi.fn(),
+        SetPeerRCONAccess: vi.fn(),
+        StartLANSignaling: vi.fn(),
+        StopLANSignaling: vi.fn(),
+        BrowseLANTunnels: vi.fn().mockResolvedValue([]),
+        JoinLANTunnel: vi.fn(),
+        StartLANDiscovery: vi.fn().mockResolvedValue(undefined),
+        StopLANDiscovery: vi.fn(),
+        ListLANWorlds: vi.fn().mockResolvedValue([]),
+        SelectLANWorld: vi.fn(),
+        ChooseServerDirectory: vi.fn(),
+        ClearServerDirectory: vi.fn(),
+        GetServerProperties: vi.fn().mockResolvedValue({}),
+        GetServerState: vi.fn().mockResolvedValue("stopped"),
+        StartServer: vi.fn(),
+        StopServer: vi.fn(),
+        SendServerCommand: vi.fn(),
+        StartClipboardWatch: vi.fn(),
+        StopClipboardWatch: vi.fn(),
+        SetWatchClipboard: vi.fn(),
       },
     },
   };
@@ -1,17 +1,130 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
+import {main} from '../models';
 import {webrtc} from '../models';
 
 export function AcceptAnswer(arg1:string):Promise<void>;
 
 export function AcceptOffer(arg1:string):Promise<string>;
 
+export function AcceptOfferWithProfile(arg1:string,arg2:string):Promise<string>;
+
+export function BrowseLANTunnels():Promise<Array<main.LANTunnel>>;
+
+export function ChooseServerDirectory():Promise<main.ServerProperties>;
+
+export function ClearServerDirectory():Promise<void>;
+
 export function CreateOffer():Promise<string>;
 
+export function CreateOfferWithProfile(arg1:string):Promise<string>;
+
+export function CreateProfile(arg1:main.Profile):Promise<main.Profile>;
+
+export function DeleteProfile(arg1:string):Promise<void>;
+
+export function DetectNAT():Promise<main.NATReport>;
+
+export function DuplicateProfile(arg1:string):Promise<main.Profile>;
+
+export function ExportIdentity(arg1:string):Promise<void>;
+
 export function ExportToFile(arg1:string,arg2:string):Promise<void>;
 
+export function ForgetPeer(arg1:string):Promise<void>;
+
+export function GetActiveProfile():Promise<main.Profile>;
+
+export function GetAllowlist():Promise<main.AllowlistConfig>;
+
+export function GetChatHistory():Promise<Array<main.ChatMessage>>;
+
+export function GetIdentity():Promise<main.Identity>;
+
+export function GetPortMappings():Promise<Array<main.PortMapping>>;
+
+export function GetProxyProtocol():Promise<boolean>;
+
+export function GetRCONConfig():Promise<main.RCONConfig>;
+
+export function GetServerProperties():Promise<main.ServerProperties>;
+
+export function GetServerState():Promise<string>;
+
+export function GetSettings():Promise<main.Settings>;
+
+export function GetStats():Promise<main.TunnelStats>;
+
+export function HostViaFolder(arg1:string):Promise<string>;
+
 export function ImportFromFile(arg1:string):Promise<string>;
 
+export function ImportIdentity(arg1:string):Promise<main.Identity>;
+
+export function JoinLANTunnel(arg1:main.LANTunnel):Promise<void>;
+
+export function ListKnownPeers():Promise<Array<main.KnownPeer>>;
+
+export function ListLANWorlds():Promise<Array<main.LANWorld>>;
+
+export function ListProfiles():Promise<Array<main.Profile>>;
+
+export function PingMinecraftServer():Promise<main.ServerStatus>;
+
+export function QueryHistory(arg1:main.HistoryQuery):Promise<Array<main.SessionRecord>>;
+
+export function ReconnectPeer(arg1:string):Promise<void>;
+
+export function RotateIdentity():Promise<main.Identity>;
+
+export function RunRCONCommand(arg1:string):Promise<string>;
+
+export function RunRemoteRCONCommand(arg1:string):Promise<string>;
+
+export function SelectLANWorld(arg1:string):Promise<void>;
+
+export function SendChatMessage(arg1:string):Promise<main.ChatMessage>;
+
+export function SendServerCommand(arg1:string):Promise<void>;
+
+export function SetAllowlist(arg1:main.AllowlistConfig):Promise<void>;
+
+export function SetPeerRCONAccess(arg1:string,arg2:boolean):Promise<void>;
+
+export function SetPortMappings(arg1:Array<main.PortMapping>):Promise<void>;
+
+export function SetProxyProtocol(arg1:boolean):Promise<void>;
+
+export function SetRCONConfig(arg1:main.RCONConfig):Promise<void>;
+
+export function SetServerDirectory(arg1:string):Promise<main.ServerProperties>;
+
+export function SetTargetAddress(arg1:string):Promise<void>;
+
+export function SetWatchClipboard(arg1:boolean):Promise<void>;
+
+export function StartClipboardWatch():Promise<void>;
+
 export function StartHostProxy(arg1:webrtc.DataChannel,arg2:string):Promise<void>;
 
 export function StartJoinerProxy(arg1:webrtc.DataChannel,arg2:string):Promise<void>;
+
+export function StartLANDiscovery():Promise<void>;
+
+export function StartLANSignaling():Promise<void>;
+
+export function StartServer(arg1:main.ServerLaunchConfig):Promise<void>;
+
+export function StopClipboardWatch():Promise<void>;
+
+export function StopFolderSignaling():Promise<void>;
+
+export function StopLANDiscovery():Promise<void>;
+
+export function StopLANSignaling():Promise<void>;
+
+export function StopServer():Promise<void>;
+
+export function UpdateSettings(arg1:main.Settings):Promise<void>;
+
+export function WatchFolderForOffers(arg1:string):Promise<void>;
@@ -10,18 +10,202 @@ export function AcceptOffer(arg1) {
   return window['go']['main']['App']['AcceptOffer'](arg1);
 }
 
+export function AcceptOfferWithProfile(arg1, arg2) {
+  return window['go']['main']['App']['AcceptOfferWithProfile'](arg1, arg2);
+}
+
+export function BrowseLANTunnels() {
+  return window['go']['main']['App']['BrowseLANTunnels']();
+}
+
+export function ChooseServerDirectory() {
+  return window['go']['main']['App']['ChooseServerDirectory']();
+}
+
+export function ClearServerDirectory() {
+  return window['go']['main']['App']['ClearServerDirectory']();
+}
+
 export function CreateOffer() {
   return window['go']['main']['App']['CreateOffer']();
 }
 
+export function CreateOfferWithProfile(arg1) {
+  return window['go']['main']['App']['CreateOfferWithProfile'](arg1);
+}
+
+export function CreateProfile(arg1) {
+  return window['go']['main']['App']['CreateProfile'](arg1);
+}
+
+export function DeleteProfile(arg1) {
+  return window['go']['main']['App']['DeleteProfile'](arg1);
+}
+
+export function DetectNAT() {
+  return window['go']['main']['App']['DetectNAT']();
+}
+
+export function DuplicateProfile(arg1) {
+  return window['go']['main']['App']['DuplicateProfile'](arg1);
+}
+
+export function ExportIdentity(arg1) {
+  return window['go']['main']['App']['ExportIdentity'](arg1);
+}
+
 export function ExportToFile(arg1, arg2) {
   return window['go']['main']['App']['ExportToFile'](arg1, arg2);
 }
 
+export function ForgetPeer(arg1) {
+  return window['go']['main']['App']['ForgetPeer'](arg1);
+}
+
+export function GetActiveProfile() {
+  return window['go']['main']['App']['GetActiveProfile']();
+}
+
+export function GetAllowlist() {
+  return window['go']['main']['App']['GetAllowlist']();
+}
+
+export function GetChatHistory() {
+  return window['go']['main']['App']['GetChatHistory']();
+}
+
+export function GetIdentity() {
+  return window['go']['main']['App']['GetIdentity']();
+}
+
+export function GetPortMappings() {
+  return window['go']['main']['App']['GetPortMappings']();
+}
+
+export function GetProxyProtocol() {
+  return window['go']['main']['App']['GetProxyProtocol']();
+}
+
+export function GetRCONConfig() {
+  return window['go']['main']['App']['GetRCONConfig']();
+}
+
+export function GetServerProperties() {
+  return window['go']['main']['App']['GetServerProperties']();
+}
+
+export function GetServerState() {
+  return window['go']['main']['App']['GetServerState']();
+}
+
+export function GetSettings() {
+  return window['go']['main']['App']['GetSettings']();
+}
+
+export function GetStats() {
+  return window['go']['main']['App']['GetStats']();
+}
+
+export function HostViaFolder(arg1) {
+  return window['go']['main']['App']['HostViaFolder'](arg1);
+}
+
 export function ImportFromFile(arg1) {
   return window['go']['main']['App']['ImportFromFile'](arg1);
 }
 
+export function ImportIdentity(arg1) {
+  return window['go']['main']['App']['ImportIdentity'](arg1);
+}
+
+export function JoinLANTunnel(arg1) {
+  return window['go']['main']['App']['JoinLANTunnel'](arg1);
+}
+
+export function ListKnownPeers() {
+  return window['go']['main']['App']['ListKnownPeers']();
+}
+
+export function ListLANWorlds() {
+  return window['go']['main']['App']['ListLANWorlds']();
+}
+
+export function ListProfiles() {
+  return window['go']['main']['App']['ListProfiles']();
+}
+
+export function PingMinecraftServer() {
+  return window['go']['main']['App']['PingMinecraftServer']();
+}
+
+export function QueryHistory(arg1) {
+  return window['go']['main']['App']['QueryHistory'](arg1);
+}
+
+export function ReconnectPeer(arg1) {
+  return window['go']['main']['App']['ReconnectPeer'](arg1);
+}
+
+export function RotateIdentity() {
+  return window['go']['main']['App']['RotateIdentity']();
+}
+
+export function RunRCONCommand(arg1) {
+  return window['go']['main']['App']['RunRCONCommand'](arg1);
+}
+
+export function RunRemoteRCONCommand(arg1) {
+  return window['go']['main']['App']['RunRemoteRCONCommand'](arg1);
+}
+
+export function SelectLANWorld(arg1) {
+  return window['go']['main']['App']['SelectLANWorld'](arg1);
+}
+
+export function SendChatMessage(arg1) {
+  return window['go']['main']['App']['SendChatMessage'](arg1);
+}
+
+export function SendServerCommand(arg1) {
+  return window['go']['main']['App']['SendServerCommand'](arg1);
+}
+
+export function SetAllowlist(arg1) {
+  return window['go']['main']['App']['SetAllowlist'](arg1);
+}
+
+export function SetPeerRCONAccess(arg1, arg2) {
+  return window['go']['main']['App']['SetPeerRCONAccess'](arg1, arg2);
+}
+
+export function SetPortMappings(arg1) {
+  return window['go']['main']['App']['SetPortMappings'](arg1);
+}
+
+export function SetProxyProtocol(arg1) {
+  return window['go']['main']['App']['SetProxyProtocol'](arg1);
+}
+
+export function SetRCONConfig(arg1) {
+  return window['go']['main']['App']['SetRCONConfig'](arg1);
+}
+
+export function SetServerDirectory(arg1) {
+  return window['go']['main']['App']['SetServerDirectory'](arg1);
+}
+
+export function SetTargetAddress(arg1) {
+  return window['go']['main']['App']['SetTargetAddress'](arg1);
+}
+
+export function SetWatchClipboard(arg1) {
+  return window['go']['main']['App']['SetWatchClipboard'](arg1);
+}
+
+export function StartClipboardWatch() {
+  return window['go']['main']['App']['StartClipboardWatch']();
+}
+
 export function StartHostProxy(arg1, arg2) {
   return window['go']['main']['App']['StartHostProxy'](arg1, arg2);
 }
@@ -29,3 +213,43 @@ export function StartHostProxy(arg1, arg2) {
 export function StartJoinerProxy(arg1, arg2) {
   return window['go']['main']['App']['StartJoinerProxy'](arg1, arg2);
 }
+
+export function StartLANDiscovery() {
+  return window['go']['main']['App']['StartLANDiscovery']();
+}
+
+export function StartLANSignaling() {
+  return window['go']['main']['App']['StartLANSignaling']();
+}
+
+export function StartServer(arg1) {
+  return window['go']['main']['App']['StartServer'](arg1);
+}
+
+export function StopClipboardWatch() {
+  return window['go']['main']['App']['StopClipboardWatch']();
+}
+
+export function StopFolderSignaling() {
+  return window['go']['main']['App']['StopFolderSignaling']();
+}
+
+export function StopLANDiscovery() {
+  return window['go']['main']['App']['StopLANDiscovery']();
+}
+
+export function StopLANSignaling() {
+  return window['go']['main']['App']['StopLANSignaling']();
+}
+
+export function StopServer() {
+  return window['go']['main']['App']['StopServer']();
+}
+
+export function UpdateSettings(arg1) {
+  return window['go']['main']['App']['UpdateSettings'](arg1);
+}
+
+export function WatchFolderForOffers(arg1) {
+  return window['go']['main']['App']['WatchFolderForOffers'](arg1);
+}
@@ -1,3 +1,500 @@
+export namespace main {
+	
+	export class AllowlistConfig {
+	    enabled: boolean;
+	    players: string[];
+	    reason: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new AllowlistConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.players = source["players"];
+	        this.reason = source["reason"];
+	    }
+	}
+	
+	export class ChatMessage {
+	    text: string;
+	    sentAt: number;
+	    fromPeer: boolean;
+	    from: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ChatMessage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.text = source["text"];
+	        this.sentAt = source["sentAt"];
+	        this.fromPeer = source["fromPeer"];
+	        this.from = source["from"];
+	    }
+	}
+	
+	export class DetectedToken {
+	    kind: string;
+	    role: string;
+	    token: string;
+	    sessionId: string;
+	    fingerprint: string;
+	    peerName?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DetectedToken(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.kind = source["kind"];
+	        this.role = source["role"];
+	        this.token = source["token"];
+	        this.sessionId = source["sessionId"];
+	        this.fingerprint = source["fingerprint"];
+	        this.peerName = source["peerName"];
+	    }
+	}
+	
+	export class HistoryQuery {
+	    role: string;
+	    peer: string;
+	    since: number;
+	    limit: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new HistoryQuery(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.role = source["role"];
+	        this.peer = source["peer"];
+	        this.since = source["since"];
+	        this.limit = source["limit"];
+	    }
+	}
+	
+	export class Identity {
+	    fingerprint: string;
+	    expires: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Identity(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.fingerprint = source["fingerprint"];
+	        this.expires = source["expires"];
+	    }
+	}
+	
+	export class KnownPeer {
+	    fingerprint: string;
+	    displayName: string;
+	    lastSeen: number;
+	    role: string;
+	    reconnectKey?: string;
+	    allowRcon?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new KnownPeer(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.fingerprint = source["fingerprint"];
+	        this.displayName = source["displayName"];
+	        this.lastSeen = source["lastSeen"];
+	        this.role = source["role"];
+	        this.reconnectKey = source["reconnectKey"];
+	        this.allowRcon = source["allowRcon"];
+	    }
+	}
+	
+	export class LANTunnel {
+	    instance: string;
+	    name: string;
+	    address: string;
+	    fingerprint: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new LANTunnel(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.instance = source["instance"];
+	        this.name = source["name"];
+	        this.address = source["address"];
+	        this.fingerprint = source["fingerprint"];
+	    }
+	}
+	
+	export class LANWorld {
+	    motd: string;
+	    address: string;
+	    lastSeen: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new LANWorld(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.motd = source["motd"];
+	        this.address = source["address"];
+	        this.lastSeen = source["lastSeen"];
+	    }
+	}
+	
+	export class NATReport {
+	    mapping: string;
+	    filtering: string;
+	    mappedAddresses: string[];
+	    directLikely: boolean;
+	    warning: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new NATReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.mapping = source["mapping"];
+	        this.filtering = source["filtering"];
+	        this.mappedAddresses = source["mappedAddresses"];
+	        this.directLikely = source["directLikely"];
+	        this.warning = source["warning"];
+	    }
+	}
+	
+	export class PlayerInfo {
+	    name: string;
+	    uuid: string;
+	    protocolVersion: number;
+	    hostname: string;
+	    port: number;
+	    joinedAt: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new PlayerInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.uuid = source["uuid"];
+	        this.protocolVersion = source["protocolVersion"];
+	        this.hostname = source["hostname"];
+	        this.port = source["port"];
+	        this.joinedAt = source["joinedAt"];
+	    }
+	}
+	
+	export class PortMapping {
+	    id: string;
+	    name: string;
+	    protocol: string;
+	    listenPort: number;
+	    target?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new PortMapping(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.protocol = source["protocol"];
+	        this.listenPort = source["listenPort"];
+	        this.target = source["target"];
+	    }
+	}
+	
+	export class Profile {
+	    id: string;
+	    name: string;
+	    role: string;
+	    targetAddress?: string;
+	    listenPort?: number;
+	    iceServers: string[];
+	    passphraseRef?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Profile(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.role = source["role"];
+	        this.targetAddress = source["targetAddress"];
+	        this.listenPort = source["listenPort"];
+	        this.iceServers = source["iceServers"];
+	        this.passphraseRef = source["passphraseRef"];
+	    }
+	}
+	
+	export class RCONConfig {
+	    address: string;
+	    password: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new RCONConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.address = source["address"];
+	        this.password = source["password"];
+	    }
+	}
+	
+	export class SecuritySettings {
+	    allowlist: AllowlistConfig;
+	    proxyProtocol: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new SecuritySettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.allowlist = this.convertValues(source["allowlist"], AllowlistConfig);
+	        this.proxyProtocol = source["proxyProtocol"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class ServerLaunchConfig {
+	    javaPath: string;
+	    jarPath: string;
+	    minMemory: string;
+	    maxMemory: string;
+	    extraArgs: string[];
+	    workingDir: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ServerLaunchConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.javaPath = source["javaPath"];
+	        this.jarPath = source["jarPath"];
+	        this.minMemory = source["minMemory"];
+	        this.maxMemory = source["maxMemory"];
+	        this.extraArgs = source["extraArgs"];
+	        this.workingDir = source["workingDir"];
+	    }
+	}
+	
+	export class ServerProperties {
+	    directory: string;
+	    serverPort: number;
+	    serverIp: string;
+	    motd: string;
+	    onlineMode: boolean;
+	    enableRcon: boolean;
+	    rconPort: number;
+	    target: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ServerProperties(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.directory = source["directory"];
+	        this.serverPort = source["serverPort"];
+	        this.serverIp = source["serverIp"];
+	        this.motd = source["motd"];
+	        this.onlineMode = source["onlineMode"];
+	        this.enableRcon = source["enableRcon"];
+	        this.rconPort = source["rconPort"];
+	        this.target = source["target"];
+	    }
+	}
+	
+	export class ServerStatus {
+	    address: string;
+	    version: string;
+	    protocol: number;
+	    motd: string;
+	    onlinePlayers: number;
+	    maxPlayers: number;
+	    latencyMillis: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ServerStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.address = source["address"];
+	        this.version = source["version"];
+	        this.protocol = source["protocol"];
+	        this.motd = source["motd"];
+	        this.onlinePlayers = source["onlinePlayers"];
+	        this.maxPlayers = source["maxPlayers"];
+	        this.latencyMillis = source["latencyMillis"];
+	    }
+	}
+	
+	export class SessionRecord {
+	    startedAt: number;
+	    endedAt: number;
+	    role: string;
+	    peerFingerprint?: string;
+	    peerName?: string;
+	    target: string;
+	    bytesSent: number;
+	    bytesReceived: number;
+	    candidateType?: string;
+	    disconnectReason: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SessionRecord(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.startedAt = source["startedAt"];
+	        this.endedAt = source["endedAt"];
+	        this.role = source["role"];
+	        this.peerFingerprint = source["peerFingerprint"];
+	        this.peerName = source["peerName"];
+	        this.target = source["target"];
+	        this.bytesSent = source["bytesSent"];
+	        this.bytesReceived = source["bytesReceived"];
+	        this.candidateType = source["candidateType"];
+	        this.disconnectReason = source["disconnectReason"];
+	    }
+	}
+	
+	export class Settings {
+	    version: number;
+	    displayName: string;
+	    joinerPort: number;
+	    targetAddress: string;
+	    iceServers: string[];
+	    signalingServer?: string;
+	    natProbeServers?: string[];
+	    watchClipboard?: boolean;
+	    server: ServerLaunchConfig;
+	    timeouts: TimeoutSettings;
+	    security: SecuritySettings;
+	
+	    static createFrom(source: any = {}) {
+	        return new Settings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.version = source["version"];
+	        this.displayName = source["displayName"];
+	        this.joinerPort = source["joinerPort"];
+	        this.targetAddress = source["targetAddress"];
+	        this.iceServers = source["iceServers"];
+	        this.signalingServer = source["signalingServer"];
+	        this.natProbeServers = source["natProbeServers"];
+	        this.watchClipboard = source["watchClipboard"];
+	        this.server = this.convertValues(source["server"], ServerLaunchConfig);
+	        this.timeouts = this.convertValues(source["timeouts"], TimeoutSettings);
+	        this.security = this.convertValues(source["security"], SecuritySettings);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class TimeoutSettings {
+	    iceGatheringSeconds: number;
+	    tcpConnectSeconds: number;
+	    tcpOperationSeconds: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TimeoutSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.iceGatheringSeconds = source["iceGatheringSeconds"];
+	        this.tcpConnectSeconds = source["tcpConnectSeconds"];
+	        this.tcpOperationSeconds = source["tcpOperationSeconds"];
+	    }
+	}
+	
+	export class TunnelStats {
+	    timestamp: number;
+	    rttMillis: number;
+	    bytesSent: number;
+	    bytesReceived: number;
+	    bytesSentPerSec: number;
+	    bytesReceivedPerSec: number;
+	    candidateType: string;
+	    bufferedAmount: number;
+	    activeStreams: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TunnelStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.timestamp = source["timestamp"];
+	        this.rttMillis = source["rttMillis"];
+	        this.bytesSent = source["bytesSent"];
+	        this.bytesReceived = source["bytesReceived"];
+	        this.bytesSentPerSec = source["bytesSentPerSec"];
+	        this.bytesReceivedPerSec = source["bytesReceivedPerSec"];
+	        this.candidateType = source["candidateType"];
+	        this.bufferedAmount = source["bufferedAmount"];
+	        this.activeStreams = source["activeStreams"];
+	    }
+	}
+
+}
+
 export namespace webrtc {
 	
 	export class DataChannel {
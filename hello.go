@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+)
+
+// AppVersion is the release version, set at build time with
+// -ldflags "-X main.AppVersion=1.2.3"
+var AppVersion = "dev"
+
+// Oldest control protocol this build can still speak
+const MinControlProtocolVersion = 1
+
+// VersionMismatch is emitted on the "version-mismatch" event when the peers
+// share no protocol version
+type VersionMismatch struct {
+	Message         string `json:"message"`
+	LocalVersion    string `json:"localVersion"`
+	PeerVersion     string `json:"peerVersion"`
+	LocalProtocol   int    `json:"localProtocol"`
+	PeerProtocol    int    `json:"peerProtocol"`
+	PeerNeedsUpdate bool   `json:"peerNeedsUpdate"`
+}
+
+func (m *VersionMismatch) Error() string {
+	return m.Message
+}
+
+// localHello is what this build announces when the control channel opens
+func localHello() controlMessage {
+	return controlMessage{
+		Type:        controlTypeHello,
+		AppVersion:  AppVersion,
+		Protocol:    ControlProtocolVersion,
+		MinProtocol: MinControlProtocolVersion,
+		Features:    controlFeatures,
+	}
+}
+
+// negotiateHello picks the highest protocol version both hellos support and
+// the features both announced
+func negotiateHello(local, peer controlMessage) (int, []string, error) {
+	mode := min(local.Protocol, peer.Protocol)
+	if mode < local.MinProtocol || mode < peer.MinProtocol {
+		mismatch := &VersionMismatch{
+			LocalVersion:  local.AppVersion,
+			PeerVersion:   peer.AppVersion,
+			LocalProtocol: local.Protocol,
+			PeerProtocol:  peer.Protocol,
+		}
+		if peer.Protocol < local.Protocol {
+			mismatch.PeerNeedsUpdate = true
+			mismatch.Message = fmt.Sprintf("Your friend's minecraft-tunnel (%s) is too old to connect to yours (%s). Please ask them to update.", peer.AppVersion, local.AppVersion)
+		} else {
+			mismatch.Message = fmt.Sprintf("Your minecraft-tunnel (%s) is too old to connect to your friend's (%s). Please update.", local.AppVersion, peer.AppVersion)
+		}
+		return 0, nil, mismatch
+	}
+
+	var features []string
+	for _, f := range local.Features {
+		for _, p := range peer.Features {
+			if f == p {
+				features = append(features, f)
+				break
+			}
+		}
+	}
+	return mode, features, nil
+}
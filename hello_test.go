@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func testHello(version string, protocol, minProtocol int, features ...string) controlMessage {
+	return controlMessage{Type: controlTypeHello, AppVersion: version, Protocol: protocol, MinProtocol: minProtocol, Features: features}
+}
+
+func TestNegotiateHelloPicksHighestCommonProtocol(t *testing.T) {
+	local := testHello("1.4.0", 3, 1, "chat", "udp", "status")
+	peer := testHello("1.2.0", 2, 1, "status", "chat")
+
+	protocol, features, err := negotiateHello(local, peer)
+	if err != nil {
+		t.Fatalf("Expected compatible peers, got: %v", err)
+	}
+	if protocol != 2 {
+		t.Errorf("Expected protocol 2, got %d", protocol)
+	}
+	if !reflect.DeepEqual(features, []string{"chat", "status"}) {
+		t.Errorf("Expected shared features, got %v", features)
+	}
+}
+
+func TestNegotiateHelloRejectsOldPeer(t *testing.T) {
+	_, _, err := negotiateHello(testHello("2.0.0", 3, 3), testHello("1.0.0", 1, 1))
+
+	var mismatch *VersionMismatch
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("Expected VersionMismatch, got %v", err)
+	}
+	if !mismatch.PeerNeedsUpdate || mismatch.PeerVersion != "1.0.0" {
+		t.Errorf("Expected peer to be told to update, got %+v", mismatch)
+	}
+}
+
+func TestNegotiateHelloAsksLocalUpdate(t *testing.T) {
+	_, _, err := negotiateHello(testHello("1.0.0", 1, 1), testHello("2.0.0", 3, 2))
+
+	var mismatch *VersionMismatch
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("Expected VersionMismatch, got %v", err)
+	}
+	if mismatch.PeerNeedsUpdate {
+		t.Errorf("Expected this side to be told to update, got %+v", mismatch)
+	}
+}
+
+func TestControlIgnoresMessagesBeforeHello(t *testing.T) {
+	s := &controlSession{app: &App{ctx: testContext()}}
+	s.handle(controlMessage{Type: controlTypeStatus, Status: &statusUpdate{Online: true}})
+
+	if s.app.status != nil {
+		t.Fatal("Expected status before hello to be ignored")
+	}
+}
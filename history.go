@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	HistoryFileName = "history.jsonl"
+
+	// How many sessions QueryHistory and the history command return by default
+	DefaultHistoryLimit = 50
+)
+
+// SessionRecord is one line of the history file, written when a tunnel closes
+type SessionRecord struct {
+	StartedAt        int64  `json:"startedAt"` // Unix milliseconds
+	EndedAt          int64  `json:"endedAt"`
+	Role             string `json:"role"`
+	PeerFingerprint  string `json:"peerFingerprint,omitempty"`
+	PeerName         string `json:"peerName,omitempty"`
+	Target           string `json:"target"`
+	BytesSent        uint64 `json:"bytesSent"`
+	BytesReceived    uint64 `json:"bytesReceived"`
+	CandidateType    string `json:"candidateType,omitempty"`
+	DisconnectReason string `json:"disconnectReason"`
+}
+
+// HistoryQuery filters QueryHistory. Zero values match everything.
+type HistoryQuery struct {
+	Role  string `json:"role"`
+	Peer  string `json:"peer"`  // fingerprint, or part of the display name
+	Since int64  `json:"since"` // Unix milliseconds
+	Limit int    `json:"limit"`
+}
+
+// sessionTracker collects a session's record from the moment the peer
+// connection is created until it is written to the history
+type sessionTracker struct {
+	mu           sync.Mutex
+	record       SessionRecord
+	connected    bool
+	ended        bool
+	peerReason   string
+	baseSent     uint64
+	baseReceived uint64
+}
+
+// newSession starts tracking the peer connection being set up
+func (a *App) newSession(role, target string) {
+	a.historyMu.Lock()
+	a.session = &sessionTracker{record: SessionRecord{Role: role, Target: target}}
+	a.historyMu.Unlock()
+}
+
+func (a *App) currentSession() *sessionTracker {
+	a.historyMu.Lock()
+	defer a.historyMu.Unlock()
+	return a.session
+}
+
+// markSessionConnected starts the clock once the peers are connected
+func (a *App) markSessionConnected() {
+	t := a.currentSession()
+	if t == nil {
+		return
+	}
+	// The pair is read now: by the time the session ends the transport is
+	// usually gone
+	pair := a.selectedCandidatePair()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.connected {
+		return
+	}
+	t.connected = true
+	t.record.StartedAt = time.Now().UnixMilli()
+	if pair != nil {
+		t.record.CandidateType = pair.Local.Typ.String()
+	}
+	t.baseSent = a.traffic.bytesSent.Load()
+	t.baseReceived = a.traffic.bytesReceived.Load()
+}
+
+// setSessionPeer records who we are connected to, from the control hello
+func (a *App) setSessionPeer(fingerprint, name string) {
+	if t := a.currentSession(); t != nil {
+		t.mu.Lock()
+		t.record.PeerFingerprint = fingerprint
+		t.record.PeerName = name
+		t.mu.Unlock()
+	}
+}
+
+// setSessionPeerReason keeps the reason from the peer's goodbye, which
+// explains the disconnect better than the close that follows it
+func (a *App) setSessionPeerReason(reason string) {
+	if t := a.currentSession(); t != nil {
+		t.mu.Lock()
+		t.peerReason = reason
+		t.mu.Unlock()
+	}
+}
+
+// endSession writes the session to the history once. Sessions that never
+// connected are not recorded.
+func (a *App) endSession(reason string) {
+	t := a.currentSession()
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	if !t.connected || t.ended {
+		t.mu.Unlock()
+		return
+	}
+	t.ended = true
+	record := t.record
+	record.EndedAt = time.Now().UnixMilli()
+	record.BytesSent = a.traffic.bytesSent.Load() - t.baseSent
+	record.BytesReceived = a.traffic.bytesReceived.Load() - t.baseReceived
+	record.DisconnectReason = reason
+	if t.peerReason != "" {
+		record.DisconnectReason = "Peer: " + t.peerReason
+	}
+	t.mu.Unlock()
+
+	if err := a.appendHistory(record); err != nil {
+		a.safeEventEmit("log", fmt.Sprintf("Failed to save session history: %v", err))
+		return
+	}
+	a.safeEventEmit("session-recorded", record)
+}
+
+func (a *App) historyPath() (string, error) {
+	return a.configFile(HistoryFileName)
+}
+
+// appendHistory adds one JSON line; earlier lines are never rewritten
+func (a *App) appendHistory(record SessionRecord) error {
+	path, err := a.historyPath()
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	a.historyFileMu.Lock()
+	defer a.historyFileMu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readHistory returns every readable record, oldest first. Lines that do
+// not parse, such as one torn by a crash, are skipped.
+func readHistory(r io.Reader) ([]SessionRecord, error) {
+	var records []SessionRecord
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var record SessionRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+func (q HistoryQuery) matches(r SessionRecord) bool {
+	if q.Role != "" && r.Role != q.Role {
+		return false
+	}
+	if q.Since != 0 && r.StartedAt < q.Since {
+		return false
+	}
+	if q.Peer != "" && r.PeerFingerprint != q.Peer &&
+		!strings.Contains(strings.ToLower(r.PeerName), strings.ToLower(q.Peer)) {
+		return false
+	}
+	return true
+}
+
+// QueryHistory returns matching sessions, newest first
+func (a *App) QueryHistory(query HistoryQuery) ([]SessionRecord, error) {
+	path, err := a.historyPath()
+	if err != nil {
+		return nil, err
+	}
+	a.historyFileMu.Lock()
+	defer a.historyFileMu.Unlock()
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []SessionRecord{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	records, err := readHistory(f)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = DefaultHistoryLimit
+	}
+	result := []SessionRecord{}
+	for i := len(records) - 1; i >= 0 && len(result) < limit; i-- {
+		if query.matches(records[i]) {
+			result = append(result, records[i])
+		}
+	}
+	return result, nil
+}
+
+// runHistoryCommand implements "minecraft-tunnel history [flags]"
+func runHistoryCommand(a *App, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("history", flag.ContinueOnError)
+	flags.SetOutput(out)
+	var query HistoryQuery
+	flags.IntVar(&query.Limit, "n", DefaultHistoryLimit, "number of sessions to show")
+	flags.StringVar(&query.Role, "role", "", "only show sessions as host or join")
+	flags.StringVar(&query.Peer, "peer", "", "only show sessions with this peer (fingerprint or name)")
+	asJSON := flags.Bool("json", false, "print JSON lines instead of a table")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	records, err := a.QueryHistory(query)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		enc := json.NewEncoder(out)
+		for _, r := range records {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STARTED\tDURATION\tROLE\tPEER\tTARGET\tSENT\tRECEIVED\tPATH\tREASON")
+	for _, r := range records {
+		peer := r.PeerName
+		if peer == "" {
+			peer = r.PeerFingerprint
+		}
+		duration := time.Duration(r.EndedAt-r.StartedAt) * time.Millisecond
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%d\t%s\t%s\n",
+			time.UnixMilli(r.StartedAt).Format("2006-01-02 15:04"), duration.Round(time.Second),
+			r.Role, peer, r.Target, r.BytesSent, r.BytesReceived, r.CandidateType, r.DisconnectReason)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQueryHistoryFilters(t *testing.T) {
+	app := &App{ctx: testContext(), configDir: t.TempDir()}
+	records := []SessionRecord{
+		{StartedAt: 1000, EndedAt: 2000, Role: ProfileRoleHost, PeerName: "Sam", PeerFingerprint: "sha-256 AA", Target: "localhost:25565"},
+		{StartedAt: 3000, EndedAt: 4000, Role: ProfileRoleJoin, PeerName: "Alex", PeerFingerprint: "sha-256 BB", Target: "localhost:42517"},
+		{StartedAt: 5000, EndedAt: 6000, Role: ProfileRoleHost, PeerName: "Samantha", PeerFingerprint: "sha-256 CC", Target: "localhost:25565"},
+	}
+	for _, r := range records {
+		if err := app.appendHistory(r); err != nil {
+			t.Fatalf("appendHistory failed: %v", err)
+		}
+	}
+	// A line torn by a crash is skipped
+	path, _ := app.historyPath()
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	f.WriteString(`{"startedAt":70`)
+	f.Close()
+
+	all, err := app.QueryHistory(HistoryQuery{})
+	if err != nil {
+		t.Fatalf("QueryHistory failed: %v", err)
+	}
+	if len(all) != 3 || all[0].StartedAt != 5000 {
+		t.Fatalf("Expected three sessions newest first, got %+v", all)
+	}
+
+	cases := map[string]struct {
+		query HistoryQuery
+		want  []int64
+	}{
+		"role":        {HistoryQuery{Role: ProfileRoleHost}, []int64{5000, 1000}},
+		"name":        {HistoryQuery{Peer: "sam"}, []int64{5000, 1000}},
+		"fingerprint": {HistoryQuery{Peer: "sha-256 BB"}, []int64{3000}},
+		"since":       {HistoryQuery{Since: 3000}, []int64{5000, 3000}},
+		"limit":       {HistoryQuery{Limit: 1}, []int64{5000}},
+	}
+	for name, c := range cases {
+		got, _ := app.QueryHistory(c.query)
+		var starts []int64
+		for _, r := range got {
+			starts = append(starts, r.StartedAt)
+		}
+		if len(starts) != len(c.want) || (len(starts) > 0 && starts[0] != c.want[0]) {
+			t.Errorf("%s: expected %v, got %v", name, c.want, starts)
+		}
+	}
+}
+
+func TestRunHistoryCommand(t *testing.T) {
+	app := &App{ctx: testContext(), configDir: t.TempDir()}
+	app.appendHistory(SessionRecord{StartedAt: 1000, EndedAt: 61000, Role: ProfileRoleHost, PeerName: "Sam", Target: "localhost:25565", CandidateType: "srflx", DisconnectReason: "Closed the app"})
+
+	var out bytes.Buffer
+	if err := runHistoryCommand(app, nil, &out); err != nil {
+		t.Fatalf("runHistoryCommand failed: %v", err)
+	}
+	for _, want := range []string{"ROLE", "Sam", "1m0s", "srflx", "Closed the app"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected table to contain %q:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := runHistoryCommand(app, []string{"-json", "-role", "join"}, &out); err != nil {
+		t.Fatalf("runHistoryCommand failed: %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected no join sessions, got %s", out.String())
+	}
+}
+
+func TestSessionIsRecorded(t *testing.T) {
+	host := &App{ctx: testContext(), configDir: t.TempDir(), settings: &Settings{ICEServers: []string{DefaultICEServer}}}
+	joiner := &App{ctx: testContext(), configDir: t.TempDir(), settings: &Settings{DisplayName: "Sam", JoinerPort: freePort(t), ICEServers: []string{DefaultICEServer}}}
+	connectTestApps(t, host, joiner)
+
+	if !waitFor(t, 10*time.Second, func() bool {
+		s := host.currentControl()
+		return s != nil && s.peerDisplayName() == "Sam"
+	}) {
+		t.Fatal("Expected the tunnel to connect")
+	}
+	joiner.shutdown(context.Background())
+
+	var records []SessionRecord
+	if !waitFor(t, 10*time.Second, func() bool {
+		records, _ = host.QueryHistory(HistoryQuery{})
+		return len(records) == 1
+	}) {
+		t.Fatal("Expected the host to record the session")
+	}
+	r := records[0]
+	if r.Role != ProfileRoleHost || r.PeerName != "Sam" || r.PeerFingerprint == "" || r.Target != host.target() {
+		t.Errorf("Unexpected record %+v", r)
+	}
+	if r.CandidateType != "host" {
+		t.Errorf("Expected the local host candidate to be recorded, got %q", r.CandidateType)
+	}
+	if r.DisconnectReason != "Peer: Peer closed the app" || r.StartedAt == 0 || r.EndedAt < r.StartedAt {
+		t.Errorf("Unexpected reason or times %+v", r)
+	}
+
+	joined, _ := joiner.QueryHistory(HistoryQuery{})
+	if len(joined) != 1 || joined[0].Role != ProfileRoleJoin || joined[0].DisconnectReason != "Closed the app" {
+		t.Errorf("Unexpected joiner history %+v", joined)
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+)
+
+const (
+	IdentityFileName = "identity.pem"
+
+	// Pion's generated certificates last a month; ours outlives any friendship
+	IdentityLifetime = 10 * 365 * 24 * time.Hour
+
+	identityCommonName = "minecraft-tunnel"
+)
+
+// Identity is the public side of our DTLS certificate. Peers see the
+// fingerprint in our offers and answers and remember us by it.
+type Identity struct {
+	Fingerprint string `json:"fingerprint"`
+	Expires     int64  `json:"expires"` // Unix milliseconds
+}
+
+// generateIdentity creates a long-lived ECDSA P-256 certificate
+func generateIdentity() (*webrtc.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return webrtc.NewCertificate(key, x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: identityCommonName},
+		Issuer:       pkix.Name{CommonName: identityCommonName},
+		NotBefore:    now.Add(-24 * time.Hour),
+		NotAfter:     now.Add(IdentityLifetime),
+		Version:      2,
+	})
+}
+
+// certificateFingerprint formats the fingerprint the way sdpFingerprint
+// reads it from the peer's SDP
+func certificateFingerprint(cert *webrtc.Certificate) (string, error) {
+	fingerprints, err := cert.GetFingerprints()
+	if err != nil {
+		return "", err
+	}
+	if len(fingerprints) == 0 {
+		return "", fmt.Errorf("certificate has no fingerprint")
+	}
+	f := fingerprints[0]
+	return strings.ToLower(f.Algorithm) + " " + strings.ToUpper(f.Value), nil
+}
+
+func identityOf(cert *webrtc.Certificate) (Identity, error) {
+	fingerprint, err := certificateFingerprint(cert)
+	if err != nil {
+		return Identity{}, err
+	}
+	return Identity{Fingerprint: fingerprint, Expires: cert.Expires().UnixMilli()}, nil
+}
+
+// parseIdentity reads a certificate and private key in PEM form, as written
+// by saveIdentity and ExportIdentity
+func parseIdentity(data []byte) (*webrtc.Certificate, error) {
+	cert, err := webrtc.CertificateFromPEM(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid identity file: %w", err)
+	}
+	if time.Now().After(cert.Expires()) {
+		return nil, fmt.Errorf("identity certificate expired on %s", cert.Expires().Format("2006-01-02"))
+	}
+	return cert, nil
+}
+
+func writeIdentity(path string, cert *webrtc.Certificate) error {
+	data, err := cert.PEM()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(data), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// saveIdentity makes cert our identity, on disk and for new connections
+func (a *App) saveIdentity(cert *webrtc.Certificate) error {
+	path, err := a.configFile(IdentityFileName)
+	if err != nil {
+		return fmt.Errorf("no config directory: %w", err)
+	}
+	if err := writeIdentity(path, cert); err != nil {
+		return fmt.Errorf("failed to save identity: %w", err)
+	}
+	a.identity = cert
+	return nil
+}
+
+// loadIdentity returns our certificate, reading it from the config directory
+// or creating it the first time. Callers hold identityMu.
+func (a *App) loadIdentity() (*webrtc.Certificate, error) {
+	if a.identity != nil {
+		return a.identity, nil
+	}
+	path, err := a.configFile(IdentityFileName)
+	if err != nil {
+		return nil, fmt.Errorf("no config directory: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err == nil {
+		cert, err := parseIdentity(data)
+		if err == nil {
+			a.identity = cert
+			return cert, nil
+		}
+		// A broken or expired identity is replaced; peers will have to trust us again
+		a.safeEventEmit("log", fmt.Sprintf("Creating a new identity: %v", err))
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	cert, err := generateIdentity()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create identity: %w", err)
+	}
+	if err := a.saveIdentity(cert); err != nil {
+		return nil, err
+	}
+	return cert, nil
+}
+
+// certificates is the webrtc.Configuration.Certificates list. Without a
+// usable identity pion generates a throwaway certificate per connection.
+func (a *App) certificates() []webrtc.Certificate {
+	a.identityMu.Lock()
+	defer a.identityMu.Unlock()
+	cert, err := a.loadIdentity()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[WARN] Using a temporary certificate: %v\n", err)
+		return nil
+	}
+	return []webrtc.Certificate{*cert}
+}
+
+// GetIdentity returns our certificate fingerprint
+func (a *App) GetIdentity() (Identity, error) {
+	a.identityMu.Lock()
+	defer a.identityMu.Unlock()
+	cert, err := a.loadIdentity()
+	if err != nil {
+		return Identity{}, err
+	}
+	return identityOf(cert)
+}
+
+// RotateIdentity replaces our certificate with a new one. Friends who
+// remember the old fingerprint must connect with tokens again.
+func (a *App) RotateIdentity() (Identity, error) {
+	cert, err := generateIdentity()
+	if err != nil {
+		return Identity{}, fmt.Errorf("failed to create identity: %w", err)
+	}
+	a.identityMu.Lock()
+	defer a.identityMu.Unlock()
+	if err := a.saveIdentity(cert); err != nil {
+		return Identity{}, err
+	}
+	a.safeEventEmit("log", "Created a new identity; friends will need to reconnect with tokens")
+	return identityOf(cert)
+}
+
+// ExportIdentity writes our certificate and private key to path, to move
+// the identity to another computer
+func (a *App) ExportIdentity(path string) error {
+	a.identityMu.Lock()
+	defer a.identityMu.Unlock()
+	cert, err := a.loadIdentity()
+	if err != nil {
+		return err
+	}
+	if err := writeIdentity(path, cert); err != nil {
+		return fmt.Errorf("failed to export identity: %w", err)
+	}
+	return nil
+}
+
+// ImportIdentity replaces our certificate with one exported elsewhere
+func (a *App) ImportIdentity(path string) (Identity, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Identity{}, fmt.Errorf("failed to read identity: %w", err)
+	}
+	cert, err := parseIdentity(data)
+	if err != nil {
+		return Identity{}, err
+	}
+	a.identityMu.Lock()
+	defer a.identityMu.Unlock()
+	if err := a.saveIdentity(cert); err != nil {
+		return Identity{}, err
+	}
+	return identityOf(cert)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIdentityPersists(t *testing.T) {
+	app := &App{ctx: testContext(), configDir: t.TempDir()}
+	id, err := app.GetIdentity()
+	if err != nil {
+		t.Fatalf("GetIdentity failed: %v", err)
+	}
+	if time.UnixMilli(id.Expires).Before(time.Now().AddDate(5, 0, 0)) {
+		t.Errorf("Expected a long-lived certificate, expires %v", time.UnixMilli(id.Expires))
+	}
+
+	restarted := &App{ctx: testContext(), configDir: app.configDir}
+	again, err := restarted.GetIdentity()
+	if err != nil {
+		t.Fatalf("GetIdentity failed: %v", err)
+	}
+	if again.Fingerprint != id.Fingerprint {
+		t.Errorf("Expected the identity to survive a restart, got %s and %s", id.Fingerprint, again.Fingerprint)
+	}
+}
+
+func TestRotateIdentity(t *testing.T) {
+	app := &App{ctx: testContext(), configDir: t.TempDir()}
+	old, _ := app.GetIdentity()
+	rotated, err := app.RotateIdentity()
+	if err != nil {
+		t.Fatalf("RotateIdentity failed: %v", err)
+	}
+	if rotated.Fingerprint == old.Fingerprint {
+		t.Fatal("Expected a new fingerprint")
+	}
+	restarted := &App{ctx: testContext(), configDir: app.configDir}
+	if id, _ := restarted.GetIdentity(); id.Fingerprint != rotated.Fingerprint {
+		t.Errorf("Expected the rotated identity to be saved, got %s", id.Fingerprint)
+	}
+}
+
+func TestExportImportIdentity(t *testing.T) {
+	laptop := &App{ctx: testContext(), configDir: t.TempDir()}
+	desktop := &App{ctx: testContext(), configDir: t.TempDir()}
+	want, _ := laptop.GetIdentity()
+
+	path := filepath.Join(t.TempDir(), "identity.pem")
+	if err := laptop.ExportIdentity(path); err != nil {
+		t.Fatalf("ExportIdentity failed: %v", err)
+	}
+	got, err := desktop.ImportIdentity(path)
+	if err != nil {
+		t.Fatalf("ImportIdentity failed: %v", err)
+	}
+	if got.Fingerprint != want.Fingerprint {
+		t.Errorf("Expected imported fingerprint %s, got %s", want.Fingerprint, got.Fingerprint)
+	}
+
+	bad := filepath.Join(t.TempDir(), "bad.pem")
+	os.WriteFile(bad, []byte("not a certificate"), 0o600)
+	if _, err := desktop.ImportIdentity(bad); err == nil {
+		t.Error("Expected error importing garbage")
+	}
+	if id, _ := desktop.GetIdentity(); id.Fingerprint != want.Fingerprint {
+		t.Error("Expected a failed import to keep the current identity")
+	}
+}
+
+func TestOfferCarriesIdentity(t *testing.T) {
+	app := &App{ctx: testContext(), configDir: t.TempDir()}
+	t.Cleanup(func() { app.shutdown(context.Background()) })
+	startTestTarget(t, app)
+	offer, err := app.CreateOffer()
+	if err != nil {
+		t.Fatalf("CreateOffer failed: %v", err)
+	}
+	id, _ := app.GetIdentity()
+	if got, _ := tokenFingerprint(offer); got != id.Fingerprint {
+		t.Errorf("Expected offer fingerprint %s, got %s", id.Fingerprint, got)
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	LANMulticastAddress    = "224.0.2.60:4445"
+	LANAnnounceInterval    = 1500 * time.Millisecond
+	DefaultLANAnnounceMOTD = "Minecraft Tunnel"
+
+	// Worlds that stop announcing are dropped after this long
+	LANWorldExpiry = 6 * time.Second
+)
+
+// LANWorld is an "Open to LAN" world discovered on the host's network
+type LANWorld struct {
+	MOTD     string `json:"motd"`
+	Address  string `json:"address"`
+	LastSeen int64  `json:"lastSeen"`
+}
+
+// lanAnnouncement builds the payload Minecraft clients look for on the LAN
+func lanAnnouncement(motd string, port string) []byte {
+	return []byte(fmt.Sprintf("[MOTD]%s[/MOTD][AD]%s[/AD]", motd, port))
+}
+
+// parseLANAnnouncement extracts the MOTD and port from an announcement
+func parseLANAnnouncement(data []byte) (string, string, bool) {
+	msg := string(data)
+	motd, ok := between(msg, "[MOTD]", "[/MOTD]")
+	if !ok {
+		return "", "", false
+	}
+	port, ok := between(msg, "[AD]", "[/AD]")
+	if !ok {
+		return "", "", false
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return "", "", false
+	}
+	return motd, port, true
+}
+
+func between(s, open, close string) (string, bool) {
+	start := strings.Index(s, open)
+	if start < 0 {
+		return "", false
+	}
+	start += len(open)
+	end := strings.Index(s[start:], close)
+	if end < 0 {
+		return "", false
+	}
+	return s[start : start+end], true
+}
+
+// announceMOTD picks the MOTD for LAN announcements from the cached host status
+func (a *App) announceMOTD() string {
+	a.statusMu.Lock()
+	defer a.statusMu.Unlock()
+	if a.status == nil {
+		return DefaultLANAnnounceMOTD
+	}
+
+	motd := a.status.update.MOTD
+	if a.status.update.Online {
+		if status, err := parseStatus(a.status.update.Status); err == nil && status.MOTD != "" {
+			motd = status.MOTD
+		}
+	}
+	if motd == "" {
+		return DefaultLANAnnounceMOTD
+	}
+	// Announcements are a single line; brackets would break the framing
+	return strings.NewReplacer("\n", " ", "[", "(", "]", ")").Replace(motd)
+}
+
+// startLANAnnouncer multicasts the local proxy port so the tunneled world
+// shows up in the client's LAN Worlds list, until done is closed
+func (a *App) startLANAnnouncer(port string, done <-chan struct{}) error {
+	group, err := net.ResolveUDPAddr("udp4", a.lanMulticastAddress())
+	if err != nil {
+		return fmt.Errorf("invalid LAN multicast address: %w", err)
+	}
+	conn, err := net.DialUDP("udp4", nil, group)
+	if err != nil {
+		return fmt.Errorf("cannot open LAN announce socket: %w", err)
+	}
+
+	go func() {
+		defer conn.Close()
+		ticker := time.NewTicker(LANAnnounceInterval)
+		defer ticker.Stop()
+
+		for {
+			conn.Write(lanAnnouncement(a.announceMOTD(), port))
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (a *App) lanMulticastAddress() string {
+	if a.lanAddress != "" {
+		return a.lanAddress
+	}
+	return LANMulticastAddress
+}
+
+// StartLANDiscovery listens for "Open to LAN" announcements on the host's network
+func (a *App) StartLANDiscovery() error {
+	a.lanMu.Lock()
+	running := a.lanDiscovery != nil
+	a.lanMu.Unlock()
+	if running {
+		return nil
+	}
+
+	group, err := net.ResolveUDPAddr("udp4", a.lanMulticastAddress())
+	if err != nil {
+		return fmt.Errorf("invalid LAN multicast address: %w", err)
+	}
+	conn, err := net.ListenMulticastUDP("udp4", nil, group)
+	if err != nil {
+		return fmt.Errorf("cannot join LAN multicast group: %w", err)
+	}
+
+	a.discoverLANWorlds(conn)
+	return nil
+}
+
+// StopLANDiscovery stops listening for LAN announcements
+func (a *App) StopLANDiscovery() {
+	a.lanMu.Lock()
+	defer a.lanMu.Unlock()
+	if a.lanDiscovery != nil {
+		a.lanDiscovery.Close()
+		a.lanDiscovery = nil
+	}
+}
+
+// discoverLANWorlds records announcements read from conn until it is closed
+func (a *App) discoverLANWorlds(conn net.PacketConn) {
+	a.lanMu.Lock()
+	a.lanDiscovery = conn
+	if a.lanWorlds == nil {
+		a.lanWorlds = make(map[string]LANWorld)
+	}
+	a.lanMu.Unlock()
+
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, from, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			motd, port, ok := parseLANAnnouncement(buf[:n])
+			if !ok {
+				continue
+			}
+			udpAddr, ok := from.(*net.UDPAddr)
+			if !ok {
+				continue
+			}
+
+			world := LANWorld{
+				MOTD:     motd,
+				Address:  net.JoinHostPort(udpAddr.IP.String(), port),
+				LastSeen: time.Now().UnixMilli(),
+			}
+
+			a.lanMu.Lock()
+			_, known := a.lanWorlds[world.Address]
+			a.lanWorlds[world.Address] = world
+			a.lanMu.Unlock()
+
+			if !known {
+				a.safeEventEmit("lan-world-discovered", world)
+			}
+		}
+	}()
+}
+
+// ListLANWorlds returns the worlds announced within LANWorldExpiry
+func (a *App) ListLANWorlds() []LANWorld {
+	a.lanMu.Lock()
+	defer a.lanMu.Unlock()
+
+	cutoff := time.Now().Add(-LANWorldExpiry).UnixMilli()
+	worlds := []LANWorld{}
+	for addr, world := range a.lanWorlds {
+		if world.LastSeen < cutoff {
+			delete(a.lanWorlds, addr)
+			continue
+		}
+		worlds = append(worlds, world)
+	}
+	sort.Slice(worlds, func(i, j int) bool { return worlds[i].Address < worlds[j].Address })
+	return worlds
+}
+
+// SelectLANWorld makes a discovered world the forwarding target for the session
+func (a *App) SelectLANWorld(address string) error {
+	a.lanMu.Lock()
+	_, known := a.lanWorlds[address]
+	a.lanMu.Unlock()
+	if !known {
+		return fmt.Errorf("no LAN world discovered at %s", address)
+	}
+	return a.SetTargetAddress(address)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestLANAnnouncementFormat(t *testing.T) {
+	got := string(lanAnnouncement("My World", "42517"))
+	if got != "[MOTD]My World[/MOTD][AD]42517[/AD]" {
+		t.Fatalf("Unexpected announcement: %s", got)
+	}
+}
+
+func TestAnnounceMOTDUsesCachedStatus(t *testing.T) {
+	app := &App{ctx: testContext()}
+	if got := app.announceMOTD(); got != DefaultLANAnnounceMOTD {
+		t.Fatalf("Expected default MOTD without status, got %q", got)
+	}
+
+	app.status = &cachedStatus{update: statusUpdate{Online: true, Status: testStatusJSON}}
+	if got := app.announceMOTD(); got != "A Minecraft Server" {
+		t.Fatalf("Expected MOTD from cached status, got %q", got)
+	}
+}
+
+func TestLANAnnouncerSendsPeriodically(t *testing.T) {
+	listener, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	app := &App{ctx: testContext(), lanAddress: listener.LocalAddr().String()}
+	done := make(chan struct{})
+	defer close(done)
+	if err := app.startLANAnnouncer("42517", done); err != nil {
+		t.Fatalf("startLANAnnouncer failed: %v", err)
+	}
+
+	listener.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buf := make([]byte, 256)
+	n, _, err := listener.ReadFromUDP(buf)
+	if err != nil {
+		t.Fatalf("Expected an announcement, got: %v", err)
+	}
+	if string(buf[:n]) != "[MOTD]Minecraft Tunnel[/MOTD][AD]42517[/AD]" {
+		t.Fatalf("Unexpected announcement: %s", buf[:n])
+	}
+}
+
+func TestParseLANAnnouncement(t *testing.T) {
+	motd, port, ok := parseLANAnnouncement([]byte("[MOTD]Alex - New World[/MOTD][AD]51234[/AD]"))
+	if !ok || motd != "Alex - New World" || port != "51234" {
+		t.Fatalf("Unexpected parse result: %q %q %v", motd, port, ok)
+	}
+	if _, _, ok := parseLANAnnouncement([]byte("[MOTD]x[/MOTD][AD]notaport[/AD]")); ok {
+		t.Fatal("Expected invalid port to be rejected")
+	}
+	if _, _, ok := parseLANAnnouncement([]byte("hello")); ok {
+		t.Fatal("Expected garbage to be rejected")
+	}
+}
+
+func TestLANDiscoveryListsAndSelectsWorlds(t *testing.T) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	app := &App{ctx: testContext()}
+	app.discoverLANWorlds(conn)
+	defer app.StopLANDiscovery()
+
+	sender, err := net.DialUDP("udp4", nil, conn.LocalAddr().(*net.UDPAddr))
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer sender.Close()
+	sender.Write(lanAnnouncement("Alex - New World", "51234"))
+
+	var worlds []LANWorld
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if worlds = app.ListLANWorlds(); len(worlds) > 0 {
+			break
+		}
+	}
+	if len(worlds) != 1 {
+		t.Fatalf("Expected 1 discovered world, got %v", worlds)
+	}
+	if worlds[0].Address != "127.0.0.1:51234" || worlds[0].MOTD != "Alex - New World" {
+		t.Fatalf("Unexpected world: %+v", worlds[0])
+	}
+
+	if err := app.SelectLANWorld(worlds[0].Address); err != nil {
+		t.Fatalf("SelectLANWorld failed: %v", err)
+	}
+	if app.target() != "127.0.0.1:51234" {
+		t.Fatalf("Expected target to follow selected world, got %s", app.target())
+	}
+}
+
+func TestListLANWorldsDropsStaleEntries(t *testing.T) {
+	app := &App{ctx: testContext(), lanWorlds: map[string]LANWorld{
+		"10.0.0.2:5000": {Address: "10.0.0.2:5000", LastSeen: time.Now().Add(-time.Minute).UnixMilli()},
+	}}
+	if worlds := app.ListLANWorlds(); len(worlds) != 0 {
+		t.Fatalf("Expected stale world to be dropped, got %v", worlds)
+	}
+}
+
+func TestSelectLANWorldRejectsUnknownAddress(t *testing.T) {
+	app := &App{ctx: testContext()}
+	if err := app.SelectLANWorld("10.0.0.2:5000"); err == nil {
+		t.Fatal("Expected error for undiscovered world")
+	}
+}
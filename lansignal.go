@@ -0,0 +1,483 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	// LANSignalingService is the DNS-SD service type hosts advertise
+	LANSignalingService = "_mctunnel._tcp.local."
+	MDNSAddress         = "224.0.0.251:5353"
+
+	// How long BrowseLANTunnels listens for hosts
+	LANBrowseDuration = 2 * time.Second
+
+	// After handing out an offer the host refuses other joiners this long,
+	// so a second request cannot replace the first joiner's peer connection
+	LANOfferHold = 30 * time.Second
+
+	lanSignalingTTL = 120
+	mdnsPort        = 5353
+
+	lanOfferPath  = "/v1/offer"
+	lanAnswerPath = "/v1/answer"
+
+	// Each offer comes with a one-time nonce in this header. Only an answer
+	// posted with it is accepted, so another client on the network cannot
+	// answer in the joiner's place.
+	lanNonceHeader = "X-Tunnel-Nonce"
+)
+
+// LANTunnel is a host advertising LAN signaling on the local network
+type LANTunnel struct {
+	Instance    string `json:"instance"`
+	Name        string `json:"name"`
+	Address     string `json:"address"` // host:port of the signaling endpoint
+	Fingerprint string `json:"fingerprint"`
+}
+
+// lanSignaling is the host's advertisement and HTTP endpoint
+type lanSignaling struct {
+	instance  string
+	port      int
+	txt       []string
+	mdns      net.PacketConn
+	server    *http.Server
+	mu        sync.Mutex
+	offeredAt time.Time
+	nonce     string                 // for the pending offer, "" once used
+	pending   *webrtc.PeerConnection // the pending offer's connection
+}
+
+func newLANNonce() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (a *App) mdnsAddress() string {
+	if a.mdnsAddr != "" {
+		return a.mdnsAddr
+	}
+	return MDNSAddress
+}
+
+// listenMDNS joins the mDNS group, or listens on a plain address in tests
+func listenMDNS(address string) (net.PacketConn, error) {
+	addr, err := net.ResolveUDPAddr("udp4", address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mDNS address: %w", err)
+	}
+	if addr.IP.IsMulticast() {
+		return net.ListenMulticastUDP("udp4", nil, addr)
+	}
+	return net.ListenUDP("udp4", addr)
+}
+
+// instanceLabel is the DNS-SD instance name: the display name plus the start
+// of our fingerprint, so two hosts called Alex stay apart
+func instanceLabel(name, fingerprint string) string {
+	if name == "" {
+		name = DefaultLANAnnounceMOTD
+	}
+	name = strings.ReplaceAll(name, ".", "-")
+	if hash, ok := strings.CutPrefix(fingerprint, "sha-256 "); ok && len(hash) >= 5 {
+		name += " (" + hash[:5] + ")"
+	}
+	for len(name) > 63 {
+		name = truncateRunes(name, len([]rune(name))-1)
+	}
+	return name
+}
+
+// buildLANQuery asks for every host offering LAN signaling
+func buildLANQuery() ([]byte, error) {
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{})
+	b.StartQuestions()
+	b.Question(dnsmessage.Question{
+		Name:  dnsmessage.MustNewName(LANSignalingService),
+		Type:  dnsmessage.TypePTR,
+		Class: dnsmessage.ClassINET,
+	})
+	return b.Finish()
+}
+
+// buildLANResponse answers a query with our PTR, SRV and TXT records. Legacy
+// unicast replies echo the query ID and question, as RFC 6762 asks.
+func (s *lanSignaling) buildLANResponse(id uint16, question *dnsmessage.Question) ([]byte, error) {
+	service := dnsmessage.MustNewName(LANSignalingService)
+	instance, err := dnsmessage.NewName(s.instance + "." + LANSignalingService)
+	if err != nil {
+		return nil, err
+	}
+	hostname, _ := os.Hostname()
+	target, err := dnsmessage.NewName(strings.ReplaceAll(hostname, ".", "-") + ".local.")
+	if err != nil {
+		target = dnsmessage.MustNewName("minecraft-tunnel.local.")
+	}
+
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: id, Response: true, Authoritative: true})
+	b.EnableCompression()
+	b.StartQuestions()
+	if question != nil {
+		b.Question(*question)
+	}
+	header := func(name dnsmessage.Name) dnsmessage.ResourceHeader {
+		return dnsmessage.ResourceHeader{Name: name, Class: dnsmessage.ClassINET, TTL: lanSignalingTTL}
+	}
+	b.StartAnswers()
+	if err := b.PTRResource(header(service), dnsmessage.PTRResource{PTR: instance}); err != nil {
+		return nil, err
+	}
+	b.StartAdditionals()
+	if err := b.SRVResource(header(instance), dnsmessage.SRVResource{Port: uint16(s.port), Target: target}); err != nil {
+		return nil, err
+	}
+	if err := b.TXTResource(header(instance), dnsmessage.TXTResource{TXT: s.txt}); err != nil {
+		return nil, err
+	}
+	return b.Finish()
+}
+
+// respondMDNS answers PTR queries for our service until conn is closed
+func (s *lanSignaling) respondMDNS(conn net.PacketConn, group string) {
+	groupAddr, _ := net.ResolveUDPAddr("udp4", group)
+	buf := make([]byte, 9000)
+	for {
+		n, from, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		var p dnsmessage.Parser
+		h, err := p.Start(buf[:n])
+		if err != nil || h.Response {
+			continue
+		}
+		questions, err := p.AllQuestions()
+		if err != nil {
+			continue
+		}
+		for _, q := range questions {
+			if !strings.EqualFold(q.Name.String(), LANSignalingService) || (q.Type != dnsmessage.TypePTR && q.Type != dnsmessage.TypeALL) {
+				continue
+			}
+			// Queries from port 5353 get a multicast answer; anything else is
+			// a one-shot resolver expecting a unicast reply
+			reply, dest := []byte(nil), net.Addr(groupAddr)
+			if udp, ok := from.(*net.UDPAddr); ok && udp.Port != mdnsPort {
+				reply, err = s.buildLANResponse(h.ID, &q)
+				dest = from
+			} else {
+				reply, err = s.buildLANResponse(0, nil)
+			}
+			if err == nil {
+				conn.WriteTo(reply, dest)
+			}
+			break
+		}
+	}
+}
+
+// parseLANResponse collects the hosts in one mDNS response from source
+func parseLANResponse(msg []byte, source *net.UDPAddr) []LANTunnel {
+	var p dnsmessage.Parser
+	h, err := p.Start(msg)
+	if err != nil || !h.Response {
+		return nil
+	}
+	if err := p.SkipAllQuestions(); err != nil {
+		return nil
+	}
+
+	answers, err := p.AllAnswers()
+	if err != nil {
+		return nil
+	}
+	if err := p.SkipAllAuthorities(); err != nil {
+		return nil
+	}
+	additionals, _ := p.AllAdditionals()
+
+	var instances []string
+	ports := map[string]uint16{}
+	txts := map[string][]string{}
+	for _, r := range append(answers, additionals...) {
+		name := strings.ToLower(r.Header.Name.String())
+		switch body := r.Body.(type) {
+		case *dnsmessage.PTRResource:
+			if name == LANSignalingService {
+				instances = append(instances, body.PTR.String())
+			}
+		case *dnsmessage.SRVResource:
+			ports[name] = body.Port
+		case *dnsmessage.TXTResource:
+			txts[name] = body.TXT
+		}
+	}
+
+	var tunnels []LANTunnel
+	for _, instance := range instances {
+		key := strings.ToLower(instance)
+		port, ok := ports[key]
+		if !ok {
+			continue
+		}
+		t := LANTunnel{
+			Instance: strings.TrimSuffix(instance, "."+LANSignalingService),
+			Address:  net.JoinHostPort(source.IP.String(), strconv.Itoa(int(port))),
+		}
+		for _, kv := range txts[key] {
+			k, v, _ := strings.Cut(kv, "=")
+			switch k {
+			case "name":
+				t.Name = v
+			case "fp":
+				t.Fingerprint = v
+			}
+		}
+		tunnels = append(tunnels, t)
+	}
+	return tunnels
+}
+
+// StartLANSignaling advertises this host on the local network so joiners
+// can fetch an offer and post their answer without copying tokens
+func (a *App) StartLANSignaling() error {
+	a.lanSignalMu.Lock()
+	defer a.lanSignalMu.Unlock()
+	if a.lanSignal != nil {
+		return nil
+	}
+
+	ln, err := net.Listen("tcp4", ":0")
+	if err != nil {
+		return fmt.Errorf("cannot open LAN signaling port: %w", err)
+	}
+	mdns, err := listenMDNS(a.mdnsAddress())
+	if err != nil {
+		ln.Close()
+		return fmt.Errorf("cannot join mDNS group: %w", err)
+	}
+
+	name := a.GetSettings().DisplayName
+	identity, _ := a.GetIdentity()
+	s := &lanSignaling{
+		instance: instanceLabel(name, identity.Fingerprint),
+		port:     ln.Addr().(*net.TCPAddr).Port,
+		txt:      []string{"name=" + name, "fp=" + identity.Fingerprint, "v=" + strconv.Itoa(ControlProtocolVersion)},
+		mdns:     mdns,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(lanOfferPath, func(w http.ResponseWriter, r *http.Request) { a.serveLANOffer(s, w, r) })
+	mux.HandleFunc(lanAnswerPath, func(w http.ResponseWriter, r *http.Request) { a.serveLANAnswer(s, w, r) })
+	s.server = &http.Server{Handler: mux, ReadHeaderTimeout: TimeoutTCPOperation.Load()}
+
+	go s.server.Serve(ln)
+	go s.respondMDNS(mdns, a.mdnsAddress())
+	a.lanSignal = s
+	a.safeEventEmit("log", fmt.Sprintf("Advertising on the local network as %q", s.instance))
+	return nil
+}
+
+// StopLANSignaling stops advertising and closes the signaling endpoint
+func (a *App) StopLANSignaling() {
+	a.lanSignalMu.Lock()
+	s := a.lanSignal
+	a.lanSignal = nil
+	a.lanSignalMu.Unlock()
+	if s == nil {
+		return
+	}
+	s.mdns.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), TimeoutTCPOperation.Load())
+	defer cancel()
+	s.server.Shutdown(ctx)
+}
+
+func (a *App) serveLANOffer(s *lanSignaling, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.mu.Lock()
+	if time.Since(s.offeredAt) < LANOfferHold {
+		s.mu.Unlock()
+		http.Error(w, "host is busy with another joiner", http.StatusConflict)
+		return
+	}
+	s.offeredAt = time.Now()
+	// The last joiner never answered; its connection would otherwise be
+	// left behind when CreateOffer replaces it
+	if s.pending != nil && s.pending.SignalingState() == webrtc.SignalingStateHaveLocalOffer {
+		s.pending.Close()
+	}
+	s.pending = nil
+	s.nonce = ""
+	s.mu.Unlock()
+
+	offer, err := a.CreateOffer()
+	if err != nil {
+		s.mu.Lock()
+		s.offeredAt = time.Time{}
+		s.mu.Unlock()
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	nonce := newLANNonce()
+	s.mu.Lock()
+	s.pending = a.peerConnection
+	s.nonce = nonce
+	s.mu.Unlock()
+
+	a.safeEventEmit("log", fmt.Sprintf("Sent an offer to %s on the local network", r.RemoteAddr))
+	w.Header().Set(lanNonceHeader, nonce)
+	io.WriteString(w, offer)
+}
+
+// takeNonce reports whether nonce is the pending offer's and uses it up
+func (s *lanSignaling) takeNonce(nonce string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.nonce == "" || subtle.ConstantTimeCompare([]byte(s.nonce), []byte(nonce)) != 1 {
+		return false
+	}
+	s.nonce = ""
+	return true
+}
+
+func (a *App) serveLANAnswer(s *lanSignaling, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.takeNonce(r.Header.Get(lanNonceHeader)) {
+		http.Error(w, "no offer was sent to this client", http.StatusForbidden)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxSignalingTokenSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := a.AcceptAnswer(strings.TrimSpace(string(body))); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+	// One joiner per tunnel: stop advertising once the exchange is done
+	go a.StopLANSignaling()
+}
+
+// BrowseLANTunnels lists hosts advertising LAN signaling, listening for
+// LANBrowseDuration
+func (a *App) BrowseLANTunnels() ([]LANTunnel, error) {
+	group, err := net.ResolveUDPAddr("udp4", a.mdnsAddress())
+	if err != nil {
+		return nil, fmt.Errorf("invalid mDNS address: %w", err)
+	}
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open mDNS socket: %w", err)
+	}
+	defer conn.Close()
+
+	query, err := buildLANQuery()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.WriteTo(query, group); err != nil {
+		return nil, fmt.Errorf("failed to send mDNS query: %w", err)
+	}
+
+	found := map[string]LANTunnel{}
+	order := []string{}
+	deadline := time.Now().Add(LANBrowseDuration)
+	buf := make([]byte, 9000)
+	for {
+		conn.SetReadDeadline(deadline)
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				break
+			}
+			return nil, err
+		}
+		for _, t := range parseLANResponse(buf[:n], from) {
+			if _, seen := found[t.Address]; !seen {
+				order = append(order, t.Address)
+			}
+			found[t.Address] = t
+		}
+	}
+
+	tunnels := []LANTunnel{}
+	for _, address := range order {
+		tunnels = append(tunnels, found[address])
+	}
+	return tunnels, nil
+}
+
+// JoinLANTunnel fetches an offer from a host found by BrowseLANTunnels,
+// answers it and posts the answer back. The offer must carry the
+// fingerprint the host advertised.
+func (a *App) JoinLANTunnel(t LANTunnel) error {
+	client := http.Client{Timeout: TimeoutWebRTCICE.Load() + TimeoutTCPOperation.Load()}
+	resp, err := client.Get("http://" + t.Address + lanOfferPath)
+	if err != nil {
+		return fmt.Errorf("host unreachable: %w", err)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSignalingTokenSize))
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("host refused: %s", strings.TrimSpace(string(body)))
+	}
+
+	offer := strings.TrimSpace(string(body))
+	nonce := resp.Header.Get(lanNonceHeader)
+	if t.Fingerprint != "" {
+		if fingerprint, err := tokenFingerprint(offer); err != nil || fingerprint != t.Fingerprint {
+			return fmt.Errorf("offer from %s does not match the advertised identity", t.Address)
+		}
+	}
+	answer, err := a.AcceptOffer(offer)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, "http://"+t.Address+lanAnswerPath, strings.NewReader(answer))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set(lanNonceHeader, nonce)
+	resp, err = client.Do(req)
+	if err != nil {
+		return fmt.Errorf("host unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("host rejected the answer: %s", strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// freeUDPAddress stands in for the mDNS group, so tests do not need multicast
+func freeUDPAddress(t *testing.T) string {
+	t.Helper()
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("Failed to reserve UDP port: %v", err)
+	}
+	defer conn.Close()
+	return conn.LocalAddr().String()
+}
+
+func TestLANResponseRoundTrip(t *testing.T) {
+	s := &lanSignaling{
+		instance: instanceLabel("Alex.Home", "sha-256 AB:CD:EF"),
+		port:     40123,
+		txt:      []string{"name=Alex.Home", "fp=sha-256 AB:CD:EF", "v=1"},
+	}
+	q := dnsmessage.Question{Name: dnsmessage.MustNewName(LANSignalingService), Type: dnsmessage.TypePTR, Class: dnsmessage.ClassINET}
+	msg, err := s.buildLANResponse(7, &q)
+	if err != nil {
+		t.Fatalf("buildLANResponse failed: %v", err)
+	}
+
+	tunnels := parseLANResponse(msg, &net.UDPAddr{IP: net.IPv4(192, 168, 1, 5), Port: 5353})
+	if len(tunnels) != 1 {
+		t.Fatalf("Expected one tunnel, got %+v", tunnels)
+	}
+	want := LANTunnel{Instance: "Alex-Home (AB:CD)", Name: "Alex.Home", Address: "192.168.1.5:40123", Fingerprint: "sha-256 AB:CD:EF"}
+	if tunnels[0] != want {
+		t.Errorf("Expected %+v, got %+v", want, tunnels[0])
+	}
+
+	query, _ := buildLANQuery()
+	if got := parseLANResponse(query, &net.UDPAddr{}); got != nil {
+		t.Errorf("Expected queries to be ignored, got %+v", got)
+	}
+}
+
+func TestJoinLANTunnel(t *testing.T) {
+	mdns := freeUDPAddress(t)
+	host := &App{ctx: testContext(), configDir: t.TempDir(), mdnsAddr: mdns, settings: &Settings{DisplayName: "Alex", ICEServers: []string{DefaultICEServer}}}
+	joiner := &App{ctx: testContext(), configDir: t.TempDir(), mdnsAddr: mdns, settings: &Settings{JoinerPort: freePort(t), ICEServers: []string{DefaultICEServer}}}
+	t.Cleanup(func() {
+		host.shutdown(context.Background())
+		joiner.shutdown(context.Background())
+	})
+
+	startTestTarget(t, host)
+	if err := host.StartLANSignaling(); err != nil {
+		t.Fatalf("StartLANSignaling failed: %v", err)
+	}
+	tunnels, err := joiner.BrowseLANTunnels()
+	if err != nil {
+		t.Fatalf("BrowseLANTunnels failed: %v", err)
+	}
+	identity, _ := host.GetIdentity()
+	if len(tunnels) != 1 || tunnels[0].Name != "Alex" || tunnels[0].Fingerprint != identity.Fingerprint {
+		t.Fatalf("Expected to find Alex's tunnel, got %+v", tunnels)
+	}
+
+	if err := joiner.JoinLANTunnel(tunnels[0]); err != nil {
+		t.Fatalf("JoinLANTunnel failed: %v", err)
+	}
+	if !waitFor(t, 10*time.Second, func() bool {
+		s := joiner.currentControl()
+		return s != nil && s.peerDisplayName() == "Alex"
+	}) {
+		t.Fatal("Expected the tunnel to connect")
+	}
+	if !waitFor(t, 5*time.Second, func() bool {
+		host.lanSignalMu.Lock()
+		defer host.lanSignalMu.Unlock()
+		return host.lanSignal == nil
+	}) {
+		t.Error("Expected the host to stop advertising after the exchange")
+	}
+}
+
+func TestJoinLANTunnelChecksFingerprint(t *testing.T) {
+	host := &App{ctx: testContext(), configDir: t.TempDir(), mdnsAddr: freeUDPAddress(t), settings: &Settings{ICEServers: []string{DefaultICEServer}}}
+	joiner := &App{ctx: testContext(), configDir: t.TempDir()}
+	t.Cleanup(func() { host.shutdown(context.Background()) })
+	startTestTarget(t, host)
+	if err := host.StartLANSignaling(); err != nil {
+		t.Fatalf("StartLANSignaling failed: %v", err)
+	}
+
+	address := net.JoinHostPort("127.0.0.1", strconv.Itoa(host.lanSignal.port))
+	if err := joiner.JoinLANTunnel(LANTunnel{Address: address, Fingerprint: "sha-256 IMPOSTOR"}); err == nil {
+		t.Fatal("Expected a fingerprint mismatch")
+	}
+	// The offer is held for the first joiner
+	if err := joiner.JoinLANTunnel(LANTunnel{Address: address}); err == nil {
+		t.Error("Expected the host to refuse a second joiner while an offer is pending")
+	}
+}
+
+func TestLANAnswerNeedsOfferNonce(t *testing.T) {
+	host := &App{ctx: testContext(), configDir: t.TempDir(), mdnsAddr: freeUDPAddress(t), settings: &Settings{ICEServers: []string{DefaultICEServer}}}
+	t.Cleanup(func() { host.shutdown(context.Background()) })
+	startTestTarget(t, host)
+	if err := host.StartLANSignaling(); err != nil {
+		t.Fatalf("StartLANSignaling failed: %v", err)
+	}
+	base := "http://" + net.JoinHostPort("127.0.0.1", strconv.Itoa(host.lanSignal.port))
+
+	resp, err := http.Get(base + lanOfferPath)
+	if err != nil {
+		t.Fatalf("GET offer failed: %v", err)
+	}
+	resp.Body.Close()
+	nonce := resp.Header.Get(lanNonceHeader)
+	if resp.StatusCode != http.StatusOK || nonce == "" {
+		t.Fatalf("Expected an offer with a nonce, got %d %q", resp.StatusCode, nonce)
+	}
+
+	post := func(nonce string) int {
+		req, _ := http.NewRequest(http.MethodPost, base+lanAnswerPath, strings.NewReader("not an answer"))
+		req.Header.Set(lanNonceHeader, nonce)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("POST answer failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := post("guess"); code != http.StatusForbidden {
+		t.Errorf("Expected a wrong nonce to be refused, got %d", code)
+	}
+	if code := post(nonce); code != http.StatusBadRequest {
+		t.Errorf("Expected the nonce to reach answer parsing, got %d", code)
+	}
+	if code := post(nonce); code != http.StatusForbidden {
+		t.Errorf("Expected the nonce to work only once, got %d", code)
+	}
+}
+
+func TestLANOfferClosesUnansweredConnection(t *testing.T) {
+	host := &App{ctx: testContext(), configDir: t.TempDir(), mdnsAddr: freeUDPAddress(t), settings: &Settings{ICEServers: []string{DefaultICEServer}}}
+	t.Cleanup(func() { host.shutdown(context.Background()) })
+	startTestTarget(t, host)
+	if err := host.StartLANSignaling(); err != nil {
+		t.Fatalf("StartLANSignaling failed: %v", err)
+	}
+	s := host.lanSignal
+	url := "http://" + net.JoinHostPort("127.0.0.1", strconv.Itoa(s.port)) + lanOfferPath
+	getOffer := func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			t.Fatalf("GET offer failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	getOffer()
+	first := host.peerConnection
+	// Let the hold run out without an answer
+	s.mu.Lock()
+	s.offeredAt = time.Time{}
+	s.mu.Unlock()
+	getOffer()
+
+	if host.peerConnection == first {
+		t.Fatal("Expected a new connection for the second offer")
+	}
+	if state := first.ConnectionState(); state != webrtc.PeerConnectionStateClosed {
+		t.Errorf("Expected the unanswered connection to be closed, got %s", state)
+	}
+}
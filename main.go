@@ -2,6 +2,8 @@ package main
 
 import (
 	"embed"
+	"fmt"
+	"os"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
@@ -15,6 +17,15 @@ func main() {
 	// Create an instance of the app structure
 	app := NewApp()
 
+	// "minecraft-tunnel history" prints past sessions instead of opening the window
+	if len(os.Args) > 1 && os.Args[1] == "history" {
+		if err := runHistoryCommand(app, os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Create application with options
 	err := wails.Run(&options.App{
 		Title:  "wails-base-fresh",
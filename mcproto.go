@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Minecraft Java Edition wire format helpers. Packets are framed as
+// VarInt(length) + VarInt(packet id) + payload.
+
+const (
+	maxVarIntBytes  = 5
+	maxPacketLength = 2097151
+	maxStringLength = 32767 * 4
+
+	// Pre-1.7 clients open server list pings with 0xFE instead of a handshake
+	legacyPingByte = 0xFE
+)
+
+var (
+	errVarIntTooBig     = errors.New("VarInt is too big")
+	errIncompletePacket = errors.New("incomplete packet")
+)
+
+func appendVarInt(b []byte, v int32) []byte {
+	u := uint32(v)
+	for {
+		if u&^0x7F == 0 {
+			return append(b, byte(u))
+		}
+		b = append(b, byte(u&0x7F|0x80))
+		u >>= 7
+	}
+}
+
+func readVarInt(r io.ByteReader) (int32, error) {
+	var result uint32
+	for i := 0; i < maxVarIntBytes; i++ {
+		c, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		result |= uint32(c&0x7F) << (7 * i)
+		if c&0x80 == 0 {
+			return int32(result), nil
+		}
+	}
+	return 0, errVarIntTooBig
+}
+
+func appendString(b []byte, s string) []byte {
+	b = appendVarInt(b, int32(len(s)))
+	return append(b, s...)
+}
+
+func readString(r *bytes.Reader) (string, error) {
+	n, err := readVarInt(r)
+	if err != nil {
+		return "", err
+	}
+	if n < 0 || n > maxStringLength || int(n) > r.Len() {
+		return "", fmt.Errorf("invalid string length %d", n)
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+func readUint16(r *bytes.Reader) (uint16, error) {
+	var buf [2]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint16(buf[:]), nil
+}
+
+// encodePacket frames a packet id and payload with its VarInt length prefix
+func encodePacket(id int32, payload []byte) []byte {
+	body := appendVarInt(nil, id)
+	body = append(body, payload...)
+	out := appendVarInt(nil, int32(len(body)))
+	return append(out, body...)
+}
+
+func writePacket(w io.Writer, id int32, payload []byte) error {
+	_, err := w.Write(encodePacket(id, payload))
+	return err
+}
+
+// readPacket reads one uncompressed packet and returns its id and payload
+func readPacket(r *bufio.Reader) (int32, []byte, error) {
+	length, err := readVarInt(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if length <= 0 || length > maxPacketLength {
+		return 0, nil, fmt.Errorf("invalid packet length %d", length)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	br := bytes.NewReader(body)
+	id, err := readVarInt(br)
+	if err != nil {
+		return 0, nil, err
+	}
+	return id, body[len(body)-br.Len():], nil
+}
+
+// splitPacket parses one packet from the front of b without consuming a
+// reader. It returns errIncompletePacket when more bytes are needed and the
+// total number of bytes the packet occupies otherwise.
+func splitPacket(b []byte) (int32, []byte, int, error) {
+	r := bytes.NewReader(b)
+	length, err := readVarInt(r)
+	if err == io.EOF {
+		return 0, nil, 0, errIncompletePacket
+	}
+	if err != nil {
+		return 0, nil, 0, err
+	}
+	if length <= 0 || length > maxPacketLength {
+		return 0, nil, 0, fmt.Errorf("invalid packet length %d", length)
+	}
+	header := len(b) - r.Len()
+	if r.Len() < int(length) {
+		return 0, nil, 0, errIncompletePacket
+	}
+	body := b[header : header+int(length)]
+	br := bytes.NewReader(body)
+	id, err := readVarInt(br)
+	if err != nil {
+		return 0, nil, 0, err
+	}
+	return id, body[len(body)-br.Len():], header + int(length), nil
+}
+
+// handshake is the first packet a client sends on every connection
+type handshake struct {
+	ProtocolVersion int32
+	ServerAddress   string
+	ServerPort      uint16
+	NextState       int32
+}
+
+func parseHandshake(payload []byte) (handshake, error) {
+	var hs handshake
+	r := bytes.NewReader(payload)
+	var err error
+	if hs.ProtocolVersion, err = readVarInt(r); err != nil {
+		return hs, err
+	}
+	if hs.ServerAddress, err = readString(r); err != nil {
+		return hs, err
+	}
+	if hs.ServerPort, err = readUint16(r); err != nil {
+		return hs, err
+	}
+	if hs.NextState, err = readVarInt(r); err != nil {
+		return hs, err
+	}
+	return hs, nil
+}
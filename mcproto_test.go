@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+)
+
+func TestVarIntKnownEncodings(t *testing.T) {
+	cases := map[int32][]byte{
+		0:          {0x00},
+		1:          {0x01},
+		127:        {0x7f},
+		128:        {0x80, 0x01},
+		25565:      {0xdd, 0xc7, 0x01},
+		2147483647: {0xff, 0xff, 0xff, 0xff, 0x07},
+		-1:         {0xff, 0xff, 0xff, 0xff, 0x0f},
+	}
+	for v, want := range cases {
+		got := appendVarInt(nil, v)
+		if !bytes.Equal(got, want) {
+			t.Errorf("appendVarInt(%d) = %x, want %x", v, got, want)
+		}
+		back, err := readVarInt(bytes.NewReader(got))
+		if err != nil || back != v {
+			t.Errorf("readVarInt(%x) = %d, %v; want %d", got, back, err, v)
+		}
+	}
+}
+
+func TestReadVarIntRejectsOverlongValues(t *testing.T) {
+	_, err := readVarInt(bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0x01}))
+	if err != errVarIntTooBig {
+		t.Fatalf("Expected errVarIntTooBig, got %v", err)
+	}
+}
+
+func TestPacketRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	payload := appendString(nil, "hello")
+	if err := writePacket(&buf, 0x02, payload); err != nil {
+		t.Fatalf("writePacket failed: %v", err)
+	}
+
+	id, got, err := readPacket(bufio.NewReader(&buf))
+	if err != nil {
+		t.Fatalf("readPacket failed: %v", err)
+	}
+	if id != 0x02 {
+		t.Fatalf("Expected packet id 0x02, got 0x%02x", id)
+	}
+	s, err := readString(bytes.NewReader(got))
+	if err != nil || s != "hello" {
+		t.Fatalf("Expected 'hello', got %q (%v)", s, err)
+	}
+}
+
+func TestParseHandshake(t *testing.T) {
+	hs, err := parseHandshake(encodeHandshake(765, "play.example.com", 25565, handshakeStateLogin))
+	if err != nil {
+		t.Fatalf("parseHandshake failed: %v", err)
+	}
+	want := handshake{ProtocolVersion: 765, ServerAddress: "play.example.com", ServerPort: 25565, NextState: handshakeStateLogin}
+	if hs != want {
+		t.Fatalf("Expected %+v, got %+v", want, hs)
+	}
+}
+
+func TestParseHandshakeRejectsTruncatedPayload(t *testing.T) {
+	payload := encodeHandshake(765, "localhost", 25565, handshakeStateStatus)
+	if _, err := parseHandshake(payload[:4]); err == nil {
+		t.Fatal("Expected error for truncated handshake")
+	}
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/pion/stun"
+)
+
+const (
+	NATMappingUnknown             = "unknown"
+	NATMappingEndpointIndependent = "endpoint-independent"
+	NATMappingEndpointDependent   = "endpoint-dependent"
+
+	NATFilteringUnknown                 = "unknown"
+	NATFilteringEndpointIndependent     = "endpoint-independent"
+	NATFilteringAddressDependent        = "address-dependent"
+	NATFilteringAddressAndPortDependent = "address-and-port-dependent"
+)
+
+// Public STUN servers used for NAT probing when none are configured.
+// At least two are needed to classify mapping behavior.
+var DefaultNATProbeServers = []string{
+	"stun.l.google.com:19302",
+	"stun1.l.google.com:19302",
+	"stun.cloudflare.com:3478",
+}
+
+// NATReport describes how our NAT maps and filters UDP traffic
+type NATReport struct {
+	Mapping         string   `json:"mapping"`
+	Filtering       string   `json:"filtering"`
+	MappedAddresses []string `json:"mappedAddresses"`
+	DirectLikely    bool     `json:"directLikely"`
+	Warning         string   `json:"warning"`
+}
+
+// natProber sends STUN binding requests from a single local socket so that
+// the mapped addresses reported by different servers can be compared
+type natProber struct {
+	conn    *net.UDPConn
+	timeout time.Duration
+}
+
+// ProbeNAT classifies the local NAT using the given STUN servers (host:port).
+// Mapping is endpoint-dependent when servers see different public addresses
+// for the same local socket; filtering is only classified when the first
+// server supports RFC 5780 CHANGE-REQUEST (advertises OTHER-ADDRESS).
+func ProbeNAT(servers []string, timeout time.Duration) (NATReport, error) {
+	report := NATReport{
+		Mapping:   NATMappingUnknown,
+		Filtering: NATFilteringUnknown,
+	}
+
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		return report, fmt.Errorf("cannot open UDP socket for NAT probe: %w", err)
+	}
+	defer conn.Close()
+
+	p := &natProber{conn: conn, timeout: timeout}
+
+	var otherAddr *net.UDPAddr
+	var firstServer *net.UDPAddr
+	for _, server := range servers {
+		addr, err := p.resolve(server)
+		if err != nil {
+			continue
+		}
+		resp, err := p.request(addr, nil)
+		if err != nil {
+			continue
+		}
+
+		var mapped stun.XORMappedAddress
+		if err := mapped.GetFrom(resp); err != nil {
+			continue
+		}
+		report.MappedAddresses = append(report.MappedAddresses, mapped.String())
+
+		if firstServer == nil {
+			firstServer = addr
+			var other stun.OtherAddress
+			if err := other.GetFrom(resp); err == nil {
+				otherAddr = &net.UDPAddr{IP: other.IP, Port: other.Port}
+			}
+		}
+	}
+
+	if len(report.MappedAddresses) == 0 {
+		return report, fmt.Errorf("no STUN server answered the NAT probe")
+	}
+
+	if len(report.MappedAddresses) >= 2 {
+		report.Mapping = NATMappingEndpointIndependent
+		for _, m := range report.MappedAddresses[1:] {
+			if m != report.MappedAddresses[0] {
+				report.Mapping = NATMappingEndpointDependent
+				break
+			}
+		}
+	}
+
+	if otherAddr != nil {
+		report.Filtering = p.classifyFiltering(firstServer)
+	}
+
+	report.DirectLikely = report.Mapping != NATMappingEndpointDependent
+	if !report.DirectLikely {
+		report.Warning = "Your network uses symmetric NAT (endpoint-dependent mapping). A direct connection will only work if the other player's network has an open NAT. If connecting fails, try from a different network, such as a phone hotspot."
+	}
+
+	return report, nil
+}
+
+// resolve looks up a STUN server address, bounded by the probe timeout
+func (p *natProber) resolve(server string) (*net.UDPAddr, error) {
+	host, port, err := net.SplitHostPort(server)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+	ips, err := net.DefaultResolver.LookupIP(ctx, "ip4", host)
+	if err != nil {
+		return nil, err
+	}
+	return net.ResolveUDPAddr("udp4", net.JoinHostPort(ips[0].String(), port))
+}
+
+func (p *natProber) classifyFiltering(server *net.UDPAddr) string {
+	if _, err := p.request(server, changeRequest(true, true)); err == nil {
+		return NATFilteringEndpointIndependent
+	}
+	if _, err := p.request(server, changeRequest(false, true)); err == nil {
+		return NATFilteringAddressDependent
+	}
+	return NATFilteringAddressAndPortDependent
+}
+
+// changeRequest builds an RFC 5780 CHANGE-REQUEST attribute
+func changeRequest(changeIP, changePort bool) stun.Setter {
+	var flags byte
+	if changeIP {
+		flags |= 0x04
+	}
+	if changePort {
+		flags |= 0x02
+	}
+	return stun.RawAttribute{Type: stun.AttrChangeRequest, Value: []byte{0, 0, 0, flags}}
+}
+
+// request sends a binding request and waits for the matching response. When
+// change is set, a response from the server's own address is rejected since
+// the server ignored the CHANGE-REQUEST.
+func (p *natProber) request(server *net.UDPAddr, change stun.Setter) (*stun.Message, error) {
+	setters := []stun.Setter{stun.TransactionID, stun.BindingRequest}
+	if change != nil {
+		setters = append(setters, change)
+	}
+	req, err := stun.Build(setters...)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := p.conn.WriteToUDP(req.Raw, server); err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(p.timeout)
+	if err := p.conn.SetReadDeadline(deadline); err != nil {
+		return nil, err
+	}
+	defer p.conn.SetReadDeadline(time.Time{})
+
+	buf := make([]byte, 1500)
+	for {
+		n, from, err := p.conn.ReadFromUDP(buf)
+		if err != nil {
+			return nil, fmt.Errorf("STUN request to %s: %w", server, err)
+		}
+
+		resp := &stun.Message{Raw: append([]byte(nil), buf[:n]...)}
+		if err := resp.Decode(); err != nil {
+			continue
+		}
+		if resp.TransactionID != req.TransactionID {
+			continue
+		}
+		if change != nil && from.IP.Equal(server.IP) && from.Port == server.Port {
+			return nil, fmt.Errorf("STUN server %s ignored CHANGE-REQUEST", server)
+		}
+		return resp, nil
+	}
+}
+
+// DetectNAT probes the STUN servers from Settings.NATProbeServers, or the
+// defaults, and reports the NAT type
+func (a *App) DetectNAT() (NATReport, error) {
+	servers := a.GetSettings().NATProbeServers
+	if len(servers) == 0 {
+		servers = DefaultNATProbeServers
+	}
+	return ProbeNAT(servers, TimeoutNATProbe)
+}
+
+// warnIfDirectUnlikely runs a NAT probe and emits a "nat-warning" event when
+// a direct connection is unlikely
+func (a *App) warnIfDirectUnlikely() {
+	report, err := a.DetectNAT()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[WARN] NAT probe skipped: %v\n", err)
+		return
+	}
+	if !report.DirectLikely {
+		a.safeEventEmit("nat-warning", report)
+		a.safeEventEmit("log", report.Warning)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pion/stun"
+)
+
+// fakeSTUNServer answers binding requests with the sender's address, shifted
+// by portOffset to simulate a server seeing a different NAT mapping. When alt
+// is set, OTHER-ADDRESS is advertised and CHANGE-REQUESTs are answered from it.
+type fakeSTUNServer struct {
+	conn       *net.UDPConn
+	alt        *net.UDPConn
+	portOffset int
+}
+
+func startFakeSTUNServer(t *testing.T, portOffset int, withAlt bool) *fakeSTUNServer {
+	t.Helper()
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("Failed to start fake STUN server: %v", err)
+	}
+	s := &fakeSTUNServer{conn: conn, portOffset: portOffset}
+	if withAlt {
+		s.alt, err = net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		if err != nil {
+			t.Fatalf("Failed to start fake STUN alternate socket: %v", err)
+		}
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		if s.alt != nil {
+			s.alt.Close()
+		}
+	})
+	go s.serve()
+	return s
+}
+
+func (s *fakeSTUNServer) addr() string {
+	return s.conn.LocalAddr().String()
+}
+
+func (s *fakeSTUNServer) serve() {
+	buf := make([]byte, 1500)
+	for {
+		n, from, err := s.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		req := &stun.Message{Raw: append([]byte(nil), buf[:n]...)}
+		if err := req.Decode(); err != nil {
+			continue
+		}
+
+		setters := []stun.Setter{
+			stun.NewTransactionIDSetter(req.TransactionID),
+			stun.BindingSuccess,
+			&stun.XORMappedAddress{IP: from.IP, Port: from.Port + s.portOffset},
+		}
+		reply := s.conn
+		if s.alt != nil {
+			altAddr := s.alt.LocalAddr().(*net.UDPAddr)
+			setters = append(setters, &stun.OtherAddress{IP: altAddr.IP, Port: altAddr.Port})
+			if _, err := req.Get(stun.AttrChangeRequest); err == nil {
+				reply = s.alt
+			}
+		}
+
+		resp, err := stun.Build(setters...)
+		if err != nil {
+			continue
+		}
+		reply.WriteToUDP(resp.Raw, from)
+	}
+}
+
+func TestProbeNATEndpointIndependentMapping(t *testing.T) {
+	a := startFakeSTUNServer(t, 0, false)
+	b := startFakeSTUNServer(t, 0, false)
+
+	report, err := ProbeNAT([]string{a.addr(), b.addr()}, 500*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if report.Mapping != NATMappingEndpointIndependent {
+		t.Fatalf("Expected endpoint-independent mapping, got %s", report.Mapping)
+	}
+	if !report.DirectLikely {
+		t.Fatal("Expected direct connection to be likely")
+	}
+	if report.Filtering != NATFilteringUnknown {
+		t.Fatalf("Expected unknown filtering without OTHER-ADDRESS, got %s", report.Filtering)
+	}
+}
+
+func TestProbeNATDetectsSymmetricNAT(t *testing.T) {
+	a := startFakeSTUNServer(t, 0, false)
+	b := startFakeSTUNServer(t, 1, false)
+
+	report, err := ProbeNAT([]string{a.addr(), b.addr()}, 500*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if report.Mapping != NATMappingEndpointDependent {
+		t.Fatalf("Expected endpoint-dependent mapping, got %s", report.Mapping)
+	}
+	if report.DirectLikely {
+		t.Fatal("Expected direct connection to be unlikely")
+	}
+	if report.Warning == "" {
+		t.Fatal("Expected a warning for symmetric NAT")
+	}
+}
+
+func TestProbeNATClassifiesFiltering(t *testing.T) {
+	a := startFakeSTUNServer(t, 0, true)
+	b := startFakeSTUNServer(t, 0, false)
+
+	report, err := ProbeNAT([]string{a.addr(), b.addr()}, 500*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if report.Filtering != NATFilteringEndpointIndependent {
+		t.Fatalf("Expected endpoint-independent filtering, got %s", report.Filtering)
+	}
+}
+
+func TestProbeNATFailsWithoutServers(t *testing.T) {
+	if _, err := ProbeNAT([]string{"127.0.0.1:1"}, 100*time.Millisecond); err == nil {
+		t.Fatal("Expected error when no STUN server answers")
+	}
+}
+
+func TestDetectNATUsesConfiguredServers(t *testing.T) {
+	a := startFakeSTUNServer(t, 0, false)
+	b := startFakeSTUNServer(t, 0, false)
+
+	app := &App{ctx: testContext(), settings: &Settings{NATProbeServers: []string{a.addr(), b.addr()}}}
+	report, err := app.DetectNAT()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(report.MappedAddresses) != 2 {
+		t.Fatalf("Expected 2 mapped addresses, got %v", report.MappedAddresses)
+	}
+}
@@ -0,0 +1,439 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+)
+
+const (
+	// KnownPeersVersion is the peers file layout; older files are brought up
+	// to date by migrateKnownPeers
+	KnownPeersVersion  = 1
+	KnownPeersFileName = "peers.json"
+
+	// How often a reconnecting peer polls the signaling server, and how long
+	// it waits for the other side before giving up
+	SignalingPollInterval = time.Second
+	SignalingTimeout      = 2 * time.Minute
+
+	controlTypeReconnectKey = "reconnect-key"
+
+	signalingSlotOffer  = "offer"
+	signalingSlotAnswer = "answer"
+
+	// Offer and answer tokens are a few kilobytes; anything larger is not one
+	maxSignalingTokenSize = 64 * 1024
+)
+
+// KnownPeer is a friend we have connected to before. Peers are identified
+// by the fingerprint of their DTLS certificate.
+type KnownPeer struct {
+	Fingerprint string `json:"fingerprint"`
+	DisplayName string `json:"displayName"`
+	LastSeen    int64  `json:"lastSeen"` // Unix milliseconds
+	// Role is ours in the last session: host or join
+	Role string `json:"role"`
+	// ReconnectKey names the mailbox both peers use on the signaling server.
+	// The host picks it and shares it over the control channel.
+	ReconnectKey string `json:"reconnectKey,omitempty"`
+	// AllowRCON lets this peer run server commands when it joins our tunnel
+	AllowRCON bool `json:"allowRcon,omitempty"`
+}
+
+type knownPeersFile struct {
+	Version int         `json:"version"`
+	Peers   []KnownPeer `json:"peers"`
+}
+
+// migrateKnownPeers upgrades a peers file written by an older version in
+// place, one version step at a time
+func migrateKnownPeers(f *knownPeersFile) error {
+	if f.Version > KnownPeersVersion {
+		return fmt.Errorf("known peers were written by a newer version (%d)", f.Version)
+	}
+	return nil
+}
+
+// sdpFingerprint returns the DTLS certificate fingerprint from an SDP, e.g.
+// "sha-256 AB:CD:...", or "" if there is none
+func sdpFingerprint(sdp string) string {
+	for _, line := range strings.Split(sdp, "\n") {
+		line = strings.TrimSpace(line)
+		if value, ok := strings.CutPrefix(line, "a=fingerprint:"); ok {
+			algorithm, hash, _ := strings.Cut(value, " ")
+			return strings.ToLower(algorithm) + " " + strings.ToUpper(strings.TrimSpace(hash))
+		}
+	}
+	return ""
+}
+
+// tokenFingerprint decodes an offer or answer token and returns its fingerprint
+func tokenFingerprint(token string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return "", fmt.Errorf("invalid token format: %w", err)
+	}
+	var desc webrtc.SessionDescription
+	if err := json.Unmarshal(data, &desc); err != nil {
+		return "", fmt.Errorf("invalid session description: %w", err)
+	}
+	return sdpFingerprint(desc.SDP), nil
+}
+
+func newReconnectKey() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (a *App) knownPeersPath() (string, error) {
+	return a.configFile(KnownPeersFileName)
+}
+
+func (a *App) readKnownPeers() ([]KnownPeer, error) {
+	path, err := a.knownPeersPath()
+	if err != nil {
+		return nil, fmt.Errorf("no config directory: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f knownPeersFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid known peers file: %w", err)
+	}
+	if err := migrateKnownPeers(&f); err != nil {
+		return nil, err
+	}
+	return f.Peers, nil
+}
+
+func (a *App) writeKnownPeers(peers []KnownPeer) error {
+	path, err := a.knownPeersPath()
+	if err != nil {
+		return fmt.Errorf("no config directory: %w", err)
+	}
+	if peers == nil {
+		peers = []KnownPeer{}
+	}
+	if err := writeConfigJSON(path, knownPeersFile{Version: KnownPeersVersion, Peers: peers}); err != nil {
+		return fmt.Errorf("failed to save known peers: %w", err)
+	}
+	return nil
+}
+
+// updateKnownPeer applies change to the peer with fingerprint, adding it if
+// it is new, and saves the result
+func (a *App) updateKnownPeer(fingerprint string, change func(*KnownPeer)) (KnownPeer, error) {
+	a.peersMu.Lock()
+	defer a.peersMu.Unlock()
+	peers, err := a.readKnownPeers()
+	if err != nil {
+		return KnownPeer{}, err
+	}
+	i := 0
+	for i < len(peers) && peers[i].Fingerprint != fingerprint {
+		i++
+	}
+	if i == len(peers) {
+		peers = append(peers, KnownPeer{Fingerprint: fingerprint})
+	}
+	change(&peers[i])
+	if err := a.writeKnownPeers(peers); err != nil {
+		return KnownPeer{}, err
+	}
+	return peers[i], nil
+}
+
+// remotePeerFingerprint is the fingerprint in the peer's offer or answer
+func (a *App) remotePeerFingerprint() string {
+	pc := a.peerConnection
+	if pc == nil {
+		return ""
+	}
+	desc := pc.RemoteDescription()
+	if desc == nil {
+		return ""
+	}
+	return sdpFingerprint(desc.SDP)
+}
+
+// rememberPeer records the peer once the hellos are exchanged. The host
+// shares the peer's reconnect key, creating one the first time.
+func (s *controlSession) rememberPeer() {
+	a := s.app
+	fingerprint := a.remotePeerFingerprint()
+	if fingerprint == "" {
+		return
+	}
+	a.setSessionPeer(fingerprint, s.peerDisplayName())
+	role := ProfileRoleJoin
+	if s.host {
+		role = ProfileRoleHost
+	}
+	peer, err := a.updateKnownPeer(fingerprint, func(p *KnownPeer) {
+		if name := s.peerDisplayName(); name != "" {
+			p.DisplayName = name
+		}
+		p.LastSeen = time.Now().UnixMilli()
+		p.Role = role
+		if s.host && p.ReconnectKey == "" {
+			p.ReconnectKey = newReconnectKey()
+		}
+	})
+	if err != nil {
+		a.safeEventEmit("log", fmt.Sprintf("Failed to remember peer: %v", err))
+		return
+	}
+	if s.host && s.hasPeerFeature("reconnect") {
+		s.send(controlMessage{Type: controlTypeReconnectKey, Key: peer.ReconnectKey})
+	}
+}
+
+// storeReconnectKey keeps the key the host shared for later reconnects
+func (s *controlSession) storeReconnectKey(key string) {
+	a := s.app
+	fingerprint := a.remotePeerFingerprint()
+	if fingerprint == "" || key == "" {
+		return
+	}
+	if _, err := a.updateKnownPeer(fingerprint, func(p *KnownPeer) { p.ReconnectKey = key }); err != nil {
+		a.safeEventEmit("log", fmt.Sprintf("Failed to remember peer: %v", err))
+	}
+}
+
+// SetPeerRCONAccess grants or revokes a known peer's RCON access. The grant
+// follows the peer's certificate, so a leaked token does not carry it.
+func (a *App) SetPeerRCONAccess(fingerprint string, allowed bool) error {
+	peers, err := a.ListKnownPeers()
+	if err != nil {
+		return err
+	}
+	known := false
+	for _, p := range peers {
+		known = known || p.Fingerprint == fingerprint
+	}
+	if !known {
+		return fmt.Errorf("unknown peer %s", fingerprint)
+	}
+	_, err = a.updateKnownPeer(fingerprint, func(p *KnownPeer) { p.AllowRCON = allowed })
+	return err
+}
+
+// peerAllowsRCON reports whether the peer with fingerprint was granted RCON access
+func (a *App) peerAllowsRCON(fingerprint string) bool {
+	if fingerprint == "" {
+		return false
+	}
+	peers, err := a.ListKnownPeers()
+	if err != nil {
+		return false
+	}
+	for _, p := range peers {
+		if p.Fingerprint == fingerprint {
+			return p.AllowRCON
+		}
+	}
+	return false
+}
+
+// ListKnownPeers returns the peers we have connected to before
+func (a *App) ListKnownPeers() ([]KnownPeer, error) {
+	a.peersMu.Lock()
+	defer a.peersMu.Unlock()
+	peers, err := a.readKnownPeers()
+	if peers == nil {
+		peers = []KnownPeer{}
+	}
+	return peers, err
+}
+
+// ForgetPeer removes a known peer
+func (a *App) ForgetPeer(fingerprint string) error {
+	a.peersMu.Lock()
+	defer a.peersMu.Unlock()
+	peers, err := a.readKnownPeers()
+	if err != nil {
+		return err
+	}
+	for i, p := range peers {
+		if p.Fingerprint == fingerprint {
+			return a.writeKnownPeers(append(peers[:i], peers[i+1:]...))
+		}
+	}
+	return fmt.Errorf("no known peer %q", fingerprint)
+}
+
+// ReconnectPeer re-runs the offer/answer exchange with a known peer through
+// the signaling server, keeping the role we had last time. Both friends
+// start it; the exchange fails if the peer's certificate has changed.
+func (a *App) ReconnectPeer(fingerprint string) error {
+	server := a.GetSettings().SignalingServer
+	if server == "" {
+		return fmt.Errorf("no signaling server is configured")
+	}
+	peers, err := a.ListKnownPeers()
+	if err != nil {
+		return err
+	}
+	var peer *KnownPeer
+	for i := range peers {
+		if peers[i].Fingerprint == fingerprint {
+			peer = &peers[i]
+		}
+	}
+	if peer == nil {
+		return fmt.Errorf("no known peer %q", fingerprint)
+	}
+	if peer.ReconnectKey == "" {
+		return fmt.Errorf("%s has not shared a reconnect key; connect with tokens once more", peerLabel(*peer))
+	}
+
+	mailbox := &signalingMailbox{base: strings.TrimRight(server, "/"), key: peer.ReconnectKey}
+	ctx := a.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	a.safeEventEmit("log", fmt.Sprintf("Reconnecting to %s via %s", peerLabel(*peer), server))
+	if peer.Role == ProfileRoleHost {
+		return a.reconnectAsHost(ctx, mailbox, *peer)
+	}
+	return a.reconnectAsJoiner(ctx, mailbox, *peer)
+}
+
+func (a *App) reconnectAsHost(ctx context.Context, mailbox *signalingMailbox, peer KnownPeer) error {
+	mailbox.remove(signalingSlotAnswer)
+	offer, err := a.CreateOffer()
+	if err != nil {
+		return err
+	}
+	if err := mailbox.put(signalingSlotOffer, offer); err != nil {
+		return err
+	}
+	answer, err := mailbox.wait(ctx, signalingSlotAnswer, SignalingTimeout)
+	mailbox.remove(signalingSlotOffer)
+	if err != nil {
+		return err
+	}
+	mailbox.remove(signalingSlotAnswer)
+	if err := checkPinnedFingerprint(answer, peer); err != nil {
+		return err
+	}
+	return a.AcceptAnswer(answer)
+}
+
+func (a *App) reconnectAsJoiner(ctx context.Context, mailbox *signalingMailbox, peer KnownPeer) error {
+	offer, err := mailbox.wait(ctx, signalingSlotOffer, SignalingTimeout)
+	if err != nil {
+		return err
+	}
+	mailbox.remove(signalingSlotOffer)
+	if err := checkPinnedFingerprint(offer, peer); err != nil {
+		return err
+	}
+	answer, err := a.AcceptOffer(offer)
+	if err != nil {
+		return err
+	}
+	return mailbox.put(signalingSlotAnswer, answer)
+}
+
+// checkPinnedFingerprint refuses a token from anyone but the remembered peer
+func checkPinnedFingerprint(token string, peer KnownPeer) error {
+	fingerprint, err := tokenFingerprint(token)
+	if err != nil {
+		return err
+	}
+	if fingerprint != peer.Fingerprint {
+		return fmt.Errorf("%s's certificate has changed (got %s); connect with tokens to trust it again", peerLabel(peer), fingerprint)
+	}
+	return nil
+}
+
+func peerLabel(p KnownPeer) string {
+	if p.DisplayName != "" {
+		return p.DisplayName
+	}
+	return "Peer"
+}
+
+// signalingMailbox is a slot pair on the signaling server:
+// PUT, GET and DELETE <server>/<key>/offer and <server>/<key>/answer.
+// GET answers 404 until the slot is filled.
+type signalingMailbox struct {
+	base string
+	key  string
+}
+
+func (m *signalingMailbox) url(slot string) string {
+	return m.base + "/" + url.PathEscape(m.key) + "/" + slot
+}
+
+func (m *signalingMailbox) do(method, slot string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, m.url(slot), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	client := http.Client{Timeout: TimeoutTCPOperation.Load()}
+	return client.Do(req)
+}
+
+func (m *signalingMailbox) put(slot, token string) error {
+	resp, err := m.do(http.MethodPut, slot, []byte(token))
+	if err != nil {
+		return fmt.Errorf("signaling server unreachable: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("signaling server rejected the %s: %s", slot, resp.Status)
+	}
+	return nil
+}
+
+func (m *signalingMailbox) remove(slot string) {
+	if resp, err := m.do(http.MethodDelete, slot, nil); err == nil {
+		resp.Body.Close()
+	}
+}
+
+// wait polls slot until it is filled, the timeout passes or ctx is done
+func (m *signalingMailbox) wait(ctx context.Context, slot string, timeout time.Duration) (string, error) {
+	deadline := time.After(timeout)
+	ticker := time.NewTicker(SignalingPollInterval)
+	defer ticker.Stop()
+	for {
+		resp, err := m.do(http.MethodGet, slot, nil)
+		if err == nil {
+			body, readErr := io.ReadAll(io.LimitReader(resp.Body, maxSignalingTokenSize))
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK && readErr == nil && len(body) > 0 {
+				return strings.TrimSpace(string(body)), nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("reconnect cancelled")
+		case <-deadline:
+			return "", fmt.Errorf("peer did not answer within %v", timeout)
+		case <-ticker.C:
+		}
+	}
+}
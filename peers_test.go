@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// startMailboxServer is a minimal signaling server: PUT, GET and DELETE
+// store, fetch and clear a token per path
+func startMailboxServer(t *testing.T) string {
+	t.Helper()
+	var mu sync.Mutex
+	slots := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			slots[r.URL.Path] = string(body)
+		case http.MethodGet:
+			token, ok := slots[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			io.WriteString(w, token)
+		case http.MethodDelete:
+			delete(slots, r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestSDPFingerprint(t *testing.T) {
+	sdp := "v=0\r\no=- 1 2 IN IP4 0.0.0.0\r\na=fingerprint:SHA-256 ab:cd:ef\r\na=setup:actpass\r\n"
+	if got := sdpFingerprint(sdp); got != "sha-256 AB:CD:EF" {
+		t.Errorf("Expected normalised fingerprint, got %q", got)
+	}
+	if got := sdpFingerprint("v=0\r\n"); got != "" {
+		t.Errorf("Expected no fingerprint, got %q", got)
+	}
+}
+
+func TestKnownPeersFileRefusesNewerVersion(t *testing.T) {
+	app := &App{ctx: testContext(), configDir: t.TempDir()}
+	path := filepath.Join(app.configDir, KnownPeersFileName)
+	if err := os.WriteFile(path, []byte(`{"version":99,"peers":[]}`), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if _, err := app.ListKnownPeers(); err == nil {
+		t.Error("Expected a file from a newer version to be refused")
+	}
+}
+
+func TestConnectedPeersAreRemembered(t *testing.T) {
+	host := &App{ctx: testContext(), configDir: t.TempDir(), settings: &Settings{DisplayName: "Alex", ICEServers: []string{DefaultICEServer}}}
+	joiner := &App{ctx: testContext(), configDir: t.TempDir(), settings: &Settings{DisplayName: "Sam", JoinerPort: freePort(t), ICEServers: []string{DefaultICEServer}}}
+	connectTestApps(t, host, joiner)
+
+	var hostSide, joinerSide []KnownPeer
+	if !waitFor(t, 10*time.Second, func() bool {
+		hostSide, _ = host.ListKnownPeers()
+		joinerSide, _ = joiner.ListKnownPeers()
+		return len(hostSide) == 1 && len(joinerSide) == 1 && joinerSide[0].ReconnectKey != ""
+	}) {
+		t.Fatalf("Expected both peers to remember each other, got %+v and %+v", hostSide, joinerSide)
+	}
+
+	if hostSide[0].DisplayName != "Sam" || hostSide[0].Role != ProfileRoleHost {
+		t.Errorf("Unexpected host record %+v", hostSide[0])
+	}
+	if joinerSide[0].DisplayName != "Alex" || joinerSide[0].Role != ProfileRoleJoin {
+		t.Errorf("Unexpected joiner record %+v", joinerSide[0])
+	}
+	if hostSide[0].ReconnectKey != joinerSide[0].ReconnectKey {
+		t.Error("Expected both peers to share the reconnect key")
+	}
+	if want := sdpFingerprint(host.peerConnection.LocalDescription().SDP); joinerSide[0].Fingerprint != want {
+		t.Errorf("Expected joiner to remember the host's fingerprint %s, got %s", want, joinerSide[0].Fingerprint)
+	}
+
+	if err := joiner.ForgetPeer(joinerSide[0].Fingerprint); err != nil {
+		t.Fatalf("ForgetPeer failed: %v", err)
+	}
+	if peers, _ := joiner.ListKnownPeers(); len(peers) != 0 {
+		t.Errorf("Expected no known peers, got %+v", peers)
+	}
+}
+
+func TestReconnectPeerNeedsSignalingServer(t *testing.T) {
+	app := &App{ctx: testContext(), configDir: t.TempDir(), settings: &Settings{}}
+	if err := app.ReconnectPeer("sha-256 AB"); err == nil || !strings.Contains(err.Error(), "signaling server") {
+		t.Errorf("Expected a missing signaling server error, got %v", err)
+	}
+}
+
+func TestReconnectPeerRejectsChangedCertificate(t *testing.T) {
+	server := startMailboxServer(t)
+	ctx, cancel := context.WithCancel(testContext())
+	defer cancel()
+	host := &App{ctx: ctx, configDir: t.TempDir(), settings: &Settings{SignalingServer: server}}
+	joiner := &App{ctx: testContext(), configDir: t.TempDir(), settings: &Settings{SignalingServer: server}}
+	t.Cleanup(func() {
+		host.shutdown(context.Background())
+		joiner.shutdown(context.Background())
+	})
+
+	host.updateKnownPeer("sha-256 JOINER", func(p *KnownPeer) { p.Role = ProfileRoleHost; p.ReconnectKey = "k1" })
+	joiner.updateKnownPeer("sha-256 NOT:THE:HOST", func(p *KnownPeer) {
+		p.DisplayName = "Alex"
+		p.Role = ProfileRoleJoin
+		p.ReconnectKey = "k1"
+	})
+
+	startTestTarget(t, host)
+	hostErr := make(chan error, 1)
+	go func() { hostErr <- host.ReconnectPeer("sha-256 JOINER") }()
+
+	err := joiner.ReconnectPeer("sha-256 NOT:THE:HOST")
+	if err == nil || !strings.Contains(err.Error(), "certificate has changed") {
+		t.Fatalf("Expected a pinning error, got %v", err)
+	}
+	cancel()
+	if err := <-hostErr; err == nil {
+		t.Error("Expected the host to give up without an answer")
+	}
+}
+
+func TestReconnectKnownPeer(t *testing.T) {
+	server := startMailboxServer(t)
+	hostDir, joinerDir := t.TempDir(), t.TempDir()
+	newHost := func() *App {
+		return &App{ctx: testContext(), configDir: hostDir, settings: &Settings{DisplayName: "Alex", ICEServers: []string{DefaultICEServer}, SignalingServer: server}}
+	}
+	newJoiner := func() *App {
+		return &App{ctx: testContext(), configDir: joinerDir, settings: &Settings{DisplayName: "Sam", JoinerPort: freePort(t), ICEServers: []string{DefaultICEServer}, SignalingServer: server}}
+	}
+
+	// First session with tokens
+	host, joiner := newHost(), newJoiner()
+	connectTestApps(t, host, joiner)
+	var known []KnownPeer
+	if !waitFor(t, 10*time.Second, func() bool {
+		known, _ = joiner.ListKnownPeers()
+		return len(known) == 1 && known[0].ReconnectKey != ""
+	}) {
+		t.Fatal("Expected the joiner to learn a reconnect key")
+	}
+	hostKnown, _ := host.ListKnownPeers()
+	host.shutdown(context.Background())
+	joiner.shutdown(context.Background())
+
+	// Both restart and reconnect without tokens
+	host, joiner = newHost(), newJoiner()
+	t.Cleanup(func() {
+		host.shutdown(context.Background())
+		joiner.shutdown(context.Background())
+	})
+	startTestTarget(t, host)
+	hostErr := make(chan error, 1)
+	go func() { hostErr <- host.ReconnectPeer(hostKnown[0].Fingerprint) }()
+	if err := joiner.ReconnectPeer(known[0].Fingerprint); err != nil {
+		t.Fatalf("Joiner ReconnectPeer failed: %v", err)
+	}
+	if err := <-hostErr; err != nil {
+		t.Fatalf("Host ReconnectPeer failed: %v", err)
+	}
+	if !waitFor(t, 10*time.Second, func() bool {
+		s := joiner.currentControl()
+		return s != nil && s.peerDisplayName() == "Alex"
+	}) {
+		t.Fatal("Expected the reconnected peers to exchange hellos")
+	}
+}
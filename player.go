@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+)
+
+const (
+	packetLoginStart = 0x00
+
+	// Give up inspecting a stream that has not produced a Login Start by now
+	maxInspectBytes = 64 * 1024
+
+	// Protocol versions where Login Start gained a player UUID
+	protocolLoginStartOptionalUUID = 761 // 1.19.3
+	protocolLoginStartUUID         = 764 // 1.20.2
+)
+
+// errNotInspectable means a stream could not be parsed far enough to know
+// who is logging in
+var errNotInspectable = errors.New("connection could not be inspected")
+
+// PlayerInfo identifies a player from the first packets of a host stream
+type PlayerInfo struct {
+	Name            string `json:"name"`
+	UUID            string `json:"uuid"`
+	ProtocolVersion int    `json:"protocolVersion"`
+	Hostname        string `json:"hostname"`
+	Port            int    `json:"port"`
+	JoinedAt        int64  `json:"joinedAt"`
+}
+
+// formatUUID renders 16 raw bytes in the dashed form Minecraft uses
+func formatUUID(b []byte) string {
+	h := hex.EncodeToString(b)
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
+
+// parseLoginStart reads the player name and, on protocols that send it, UUID
+func parseLoginStart(protocol int32, payload []byte) (string, string, error) {
+	r := bytes.NewReader(payload)
+	name, err := readString(r)
+	if err != nil {
+		return "", "", err
+	}
+
+	var raw [16]byte
+	switch {
+	case protocol >= protocolLoginStartUUID:
+		if _, err := io.ReadFull(r, raw[:]); err != nil {
+			return name, "", nil
+		}
+		return name, formatUUID(raw[:]), nil
+	case protocol >= protocolLoginStartOptionalUUID:
+		if hasUUID, err := r.ReadByte(); err != nil || hasUUID == 0 {
+			return name, "", nil
+		}
+		if _, err := io.ReadFull(r, raw[:]); err != nil {
+			return name, "", nil
+		}
+		return name, formatUUID(raw[:]), nil
+	}
+	return name, "", nil
+}
+
+// loginInspector holds back tunnel bytes until the Handshake and, for login
+// and transfer connections, the Login Start packet have been parsed. Bytes
+// are released unchanged. Anything it cannot parse is passed through as-is,
+// unless strict, when it is refused with errNotInspectable instead.
+type loginInspector struct {
+	buf  []byte
+	done bool
+}
+
+// feed returns the bytes that may be forwarded now and the player once the
+// Login Start has been seen. strict is set while the allowlist is on.
+func (l *loginInspector) feed(data []byte, strict bool) ([]byte, *PlayerInfo, error) {
+	if l.done {
+		return data, nil, nil
+	}
+	l.buf = append(l.buf, data...)
+
+	id, payload, n, err := splitPacket(l.buf)
+	if err == errIncompletePacket && len(l.buf) < maxInspectBytes {
+		return nil, nil, nil
+	}
+	if err != nil || id != packetHandshake {
+		return l.giveUp(strict)
+	}
+	hs, err := parseHandshake(payload)
+	if err != nil {
+		return l.giveUp(strict)
+	}
+	switch hs.NextState {
+	case handshakeStateLogin, handshakeStateTransfer:
+	case handshakeStateStatus:
+		// A status connection cannot log in later
+		return l.release(), nil, nil
+	default:
+		return l.giveUp(strict)
+	}
+
+	id, payload, _, err = splitPacket(l.buf[n:])
+	if err == errIncompletePacket && len(l.buf) < maxInspectBytes {
+		return nil, nil, nil
+	}
+	if err != nil || id != packetLoginStart {
+		return l.giveUp(strict)
+	}
+	name, uuid, err := parseLoginStart(hs.ProtocolVersion, payload)
+	if err != nil {
+		return l.giveUp(strict)
+	}
+
+	return l.release(), &PlayerInfo{
+		Name:            name,
+		UUID:            uuid,
+		ProtocolVersion: int(hs.ProtocolVersion),
+		Hostname:        hs.ServerAddress,
+		Port:            int(hs.ServerPort),
+		JoinedAt:        time.Now().UnixMilli(),
+	}, nil
+}
+
+func (l *loginInspector) release() []byte {
+	out := l.buf
+	l.buf = nil
+	l.done = true
+	return out
+}
+
+// giveUp passes an unparseable stream through, or drops it when strict
+func (l *loginInspector) giveUp(strict bool) ([]byte, *PlayerInfo, error) {
+	if !strict {
+		return l.release(), nil, nil
+	}
+	l.buf = nil
+	l.done = true
+	return nil, nil, errNotInspectable
+}
+
+// hostStream is the host's end of the tunnel towards the Minecraft server.
+// The server connection is only dialed once a login has passed inspection,
+// and is redialed for the next login after the server closes it.
+type hostStream struct {
+	app     *App
+	dc      *webrtc.DataChannel
+	address string
+
+	mu        sync.Mutex
+	inspector loginInspector
+	conn      net.Conn
+	player    *PlayerInfo
+}
+
+func (a *App) newHostStream(dc *webrtc.DataChannel, address string) *hostStream {
+	return &hostStream{app: a, dc: dc, address: address}
+}
+
+// fromTunnel forwards bytes from the joiner to the server, emitting
+// "player-joined" once the login has been identified and rejecting players
+// that are not on the allowlist before anything reaches the server
+func (s *hostStream) fromTunnel(data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	allowlist := s.app.GetAllowlist()
+	forward, player, err := s.inspector.feed(data, allowlist.Enabled)
+	if err != nil {
+		s.inspector = loginInspector{}
+		s.dc.Send(loginDisconnectPacket(allowlist.reason()))
+		s.app.safeEventEmit("log", fmt.Sprintf("Rejected a connection the allowlist cannot check: %v", err))
+		return
+	}
+	if player != nil {
+		if !allowlist.allows(player) {
+			s.inspector = loginInspector{}
+			s.dc.Send(loginDisconnectPacket(allowlist.reason()))
+			s.app.safeEventEmit("player-rejected", *player)
+			s.app.safeEventEmit("log", fmt.Sprintf("Rejected %s: not on the allowlist", player.Name))
+			return
+		}
+		s.player = player
+		s.app.safeEventEmit("player-joined", *player)
+	}
+	if len(forward) == 0 {
+		return
+	}
+
+	if s.conn == nil {
+		if err := s.connectLocked(); err != nil {
+			s.app.safeEventEmit("status-change", "error")
+			s.app.safeEventEmit("log", fmt.Sprintf("Error connecting to Minecraft server: %v", err))
+			s.inspector = loginInspector{}
+			return
+		}
+	}
+	s.conn.Write(forward)
+}
+
+// connect dials the server ahead of the first login
+func (s *hostStream) connect() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != nil {
+		return nil
+	}
+	return s.connectLocked()
+}
+
+func (s *hostStream) connectLocked() error {
+	conn, err := DialTimeout("tcp", s.address, TimeoutTCPConnect.Load())
+	if err != nil {
+		return err
+	}
+	if s.app.proxyProtocol.Load() {
+		if _, err := conn.Write(s.app.tunnelProxyHeader()); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+	s.conn = conn
+	s.app.traffic.activeStreams.Add(1)
+	go s.pumpFromServer(conn)
+	return nil
+}
+
+// pumpFromServer sends server bytes to the joiner until the server closes
+func (s *hostStream) pumpFromServer(conn net.Conn) {
+	defer s.closed(conn)
+
+	buf := make([]byte, 4096)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return
+		}
+		s.dc.Send(buf[:n])
+		s.app.traffic.addSent(n)
+	}
+}
+
+// closed emits "player-left" for an identified player and readies the
+// stream for the next login
+func (s *hostStream) closed(conn net.Conn) {
+	conn.Close()
+	s.app.traffic.activeStreams.Add(-1)
+
+	s.mu.Lock()
+	player := s.player
+	s.player = nil
+	if s.conn == conn {
+		s.conn = nil
+		s.inspector = loginInspector{}
+	}
+	s.mu.Unlock()
+
+	if player != nil {
+		s.app.safeEventEmit("player-left", *player)
+	}
+}
+
+// close drops the server connection when the tunnel goes away
+func (s *hostStream) close() {
+	s.mu.Lock()
+	conn := s.conn
+	s.mu.Unlock()
+	if conn != nil {
+		conn.Close()
+	}
+}
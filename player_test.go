@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+var testPlayerUUID = []byte{0x06, 0x9a, 0x79, 0xf4, 0x44, 0xe9, 0x40, 0x26, 0xbf, 0xf3, 0x8c, 0x5e, 0x1f, 0x8e, 0x2e, 0x0f}
+
+func loginStream(protocol int32, name string, uuid []byte) []byte {
+	return handshakeStream(protocol, handshakeStateLogin, name, uuid)
+}
+
+// handshakeStream is a Handshake with nextState followed by Login Start
+func handshakeStream(protocol int32, nextState int32, name string, uuid []byte) []byte {
+	out := encodePacket(packetHandshake, encodeHandshake(protocol, "play.example.com", 25565, nextState))
+	payload := appendString(nil, name)
+	payload = append(payload, uuid...)
+	return append(out, encodePacket(packetLoginStart, payload)...)
+}
+
+func TestLoginInspectorIdentifiesPlayerAcrossSplitMessages(t *testing.T) {
+	stream := loginStream(765, "Alex", testPlayerUUID)
+	var inspector loginInspector
+
+	var forwarded []byte
+	var player *PlayerInfo
+	for i := range stream {
+		out, p, _ := inspector.feed(stream[i:i+1], false)
+		forwarded = append(forwarded, out...)
+		if p != nil {
+			player = p
+		}
+	}
+
+	if !bytes.Equal(forwarded, stream) {
+		t.Fatal("Expected bytes to be forwarded unchanged")
+	}
+	if player == nil {
+		t.Fatal("Expected player to be identified")
+	}
+	if player.Name != "Alex" || player.UUID != "069a79f4-44e9-4026-bff3-8c5e1f8e2e0f" {
+		t.Fatalf("Unexpected player: %+v", player)
+	}
+	if player.ProtocolVersion != 765 || player.Hostname != "play.example.com" || player.Port != 25565 {
+		t.Fatalf("Unexpected handshake fields: %+v", player)
+	}
+}
+
+func TestLoginInspectorPassesStatusThrough(t *testing.T) {
+	var inspector loginInspector
+	data := encodePacket(packetHandshake, encodeHandshake(765, "localhost", 25565, handshakeStateStatus))
+
+	out, player, _ := inspector.feed(data, false)
+	if player != nil || !bytes.Equal(out, data) {
+		t.Fatalf("Expected status handshake to pass through, got %x %v", out, player)
+	}
+	if more, _, _ := inspector.feed([]byte{1, 2, 3}, false); !bytes.Equal(more, []byte{1, 2, 3}) {
+		t.Fatal("Expected later bytes to pass through")
+	}
+}
+
+func TestLoginInspectorPassesGarbageThrough(t *testing.T) {
+	var inspector loginInspector
+	data := []byte{0x05, 0x7f, 0x01, 0x02, 0x03, 0x04}
+	out, player, _ := inspector.feed(data, false)
+	if player != nil || !bytes.Equal(out, data) {
+		t.Fatalf("Expected garbage to pass through, got %x %v", out, player)
+	}
+}
+
+func TestLoginInspectorTreatsTransferAsLogin(t *testing.T) {
+	var inspector loginInspector
+	stream := handshakeStream(766, handshakeStateTransfer, "Mallory", testPlayerUUID)
+	out, player, err := inspector.feed(stream, true)
+	if err != nil || player == nil || player.Name != "Mallory" || !bytes.Equal(out, stream) {
+		t.Fatalf("Expected the transfer login to be identified, got %v %v %x", player, err, out)
+	}
+}
+
+func TestLoginInspectorStrictRefusesGarbage(t *testing.T) {
+	var inspector loginInspector
+	out, player, err := inspector.feed([]byte{0x05, 0x7f, 0x01, 0x02, 0x03, 0x04}, true)
+	if err != errNotInspectable || player != nil || len(out) != 0 {
+		t.Fatalf("Expected garbage to be refused, got %x %v %v", out, player, err)
+	}
+
+	// A status ping cannot turn into a login, so it still passes
+	inspector = loginInspector{}
+	status := encodePacket(packetHandshake, encodeHandshake(765, "localhost", 25565, handshakeStateStatus))
+	if out, _, err := inspector.feed(status, true); err != nil || !bytes.Equal(out, status) {
+		t.Fatalf("Expected a status handshake to pass, got %x %v", out, err)
+	}
+}
+
+func TestParseLoginStartByProtocol(t *testing.T) {
+	name, uuid, err := parseLoginStart(340, appendString(nil, "Steve"))
+	if err != nil || name != "Steve" || uuid != "" {
+		t.Fatalf("1.12 login start: %q %q %v", name, uuid, err)
+	}
+
+	payload := append(appendString(nil, "Steve"), 1)
+	payload = append(payload, testPlayerUUID...)
+	name, uuid, err = parseLoginStart(762, payload)
+	if err != nil || name != "Steve" || uuid == "" {
+		t.Fatalf("1.19.4 login start: %q %q %v", name, uuid, err)
+	}
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/pion/webrtc/v3"
+)
+
+const (
+	PortProtocolTCP = "tcp"
+
+	// Per-connection DataChannels for extra mappings are labelled "tcp/<mapping id>"
+	tcpStreamLabelPrefix = "tcp/"
+
+	// Messages a stream may have queued before its connection is considered
+	// stuck and the stream is closed; about 1 MiB of 4 KiB chunks
+	channelInboxSize = 256
+)
+
+// PortMapping forwards a local port on the joiner to an address on the host
+type PortMapping struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Protocol   string `json:"protocol"`
+	ListenPort int    `json:"listenPort"`
+	// Target is the host's address for the mapping. It never leaves the
+	// host: the joiner only needs ids and ports.
+	Target string `json:"target,omitempty"`
+}
+
+// validatePortMappings checks a mapping table. TCP mappings may not take
+// joinerPort, where the joiner's Minecraft proxy listens.
+func validatePortMappings(mappings []PortMapping, joinerPort string) error {
+	if err := validateMappingPorts(mappings, joinerPort); err != nil {
+		return err
+	}
+	for _, m := range mappings {
+		if _, _, err := net.SplitHostPort(m.Target); err != nil {
+			return fmt.Errorf("mapping %q: invalid target %q: %w", m.ID, m.Target, err)
+		}
+	}
+	return nil
+}
+
+// validateMappingPorts checks everything but the targets, which is all the
+// joiner can check in the table it receives
+func validateMappingPorts(mappings []PortMapping, joinerPort string) error {
+	ids := map[string]bool{}
+	ports := map[string]bool{}
+	for _, m := range mappings {
+		if m.ID == "" || strings.Contains(m.ID, "/") {
+			return fmt.Errorf("mapping %q: id must be non-empty and must not contain '/'", m.Name)
+		}
+		if ids[m.ID] {
+			return fmt.Errorf("duplicate mapping id %q", m.ID)
+		}
+		ids[m.ID] = true
+
+		if m.Protocol != PortProtocolTCP && m.Protocol != PortProtocolUDP {
+			return fmt.Errorf("mapping %q: unsupported protocol %q", m.ID, m.Protocol)
+		}
+		if m.ListenPort <= 0 || m.ListenPort > 65535 {
+			return fmt.Errorf("mapping %q: invalid listen port %d", m.ID, m.ListenPort)
+		}
+		if m.Protocol == PortProtocolTCP && strconv.Itoa(m.ListenPort) == joinerPort {
+			return fmt.Errorf("mapping %q: port %d is reserved for Minecraft", m.ID, m.ListenPort)
+		}
+		key := m.Protocol + "/" + strconv.Itoa(m.ListenPort)
+		if ports[key] {
+			return fmt.Errorf("mapping %q: listen port %d used twice", m.ID, m.ListenPort)
+		}
+		ports[key] = true
+
+	}
+	return nil
+}
+
+// SetPortMappings sets the extra ports the host offers to the joiner, on top
+// of the Minecraft port. They are sent to the joiner when the tunnel opens.
+func (a *App) SetPortMappings(mappings []PortMapping) error {
+	if err := validatePortMappings(mappings, a.joinerPort()); err != nil {
+		return err
+	}
+	a.portMapMu.Lock()
+	a.portMappings = append([]PortMapping{}, mappings...)
+	a.portMapMu.Unlock()
+	return nil
+}
+
+// GetPortMappings returns the host's extra port mappings
+func (a *App) GetPortMappings() []PortMapping {
+	a.portMapMu.Lock()
+	defer a.portMapMu.Unlock()
+	return append([]PortMapping{}, a.portMappings...)
+}
+
+// peerPortMappings is the table as sent to the joiner, without targets
+func (a *App) peerPortMappings() []PortMapping {
+	mappings := a.GetPortMappings()
+	for i := range mappings {
+		mappings[i].Target = ""
+	}
+	return mappings
+}
+
+func (a *App) findPortMapping(id string) (PortMapping, bool) {
+	a.portMapMu.Lock()
+	defer a.portMapMu.Unlock()
+	for _, m := range a.portMappings {
+		if m.ID == id {
+			return m, true
+		}
+	}
+	return PortMapping{}, false
+}
+
+// applyPortMappings starts one listener per mapping received from the host
+func (a *App) applyPortMappings(mappings []PortMapping) {
+	a.stopMappingListeners()
+	if err := validateMappingPorts(mappings, a.joinerPort()); err != nil {
+		a.safeEventEmit("log", fmt.Sprintf("Ignoring port mappings from host: %v", err))
+		return
+	}
+
+	a.portMapMu.Lock()
+	a.portMappings = append([]PortMapping{}, mappings...)
+	a.portMapMu.Unlock()
+
+	for _, m := range mappings {
+		if m.Protocol == PortProtocolUDP {
+			if err := a.startUDPMapping(m); err != nil {
+				a.safeEventEmit("log", fmt.Sprintf("Cannot forward %s: %v", m.Name, err))
+				continue
+			}
+			a.safeEventEmit("log", fmt.Sprintf("Forwarding %s on UDP port %d", m.Name, m.ListenPort))
+			continue
+		}
+
+		listener, err := ListenTimeout("tcp", ":"+strconv.Itoa(m.ListenPort), TimeoutNetwork)
+		if err != nil {
+			a.safeEventEmit("log", fmt.Sprintf("Cannot forward %s: %v", m.Name, err))
+			continue
+		}
+		a.portMapMu.Lock()
+		a.mappingListeners = append(a.mappingListeners, listener)
+		a.portMapMu.Unlock()
+
+		a.safeEventEmit("log", fmt.Sprintf("Forwarding %s on port %d", m.Name, m.ListenPort))
+		go a.acceptMappedConnections(listener, m)
+	}
+	a.safeEventEmit("port-mappings", mappings)
+}
+
+func (a *App) stopMappingListeners() {
+	a.portMapMu.Lock()
+	listeners := a.mappingListeners
+	a.mappingListeners = nil
+	a.portMapMu.Unlock()
+	for _, l := range listeners {
+		l.Close()
+	}
+}
+
+// acceptMappedConnections opens a DataChannel per accepted connection
+func (a *App) acceptMappedConnections(listener net.Listener, m PortMapping) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		pc := a.peerConnection
+		if pc == nil {
+			conn.Close()
+			continue
+		}
+		dc, err := pc.CreateDataChannel(tcpStreamLabelPrefix+m.ID, nil)
+		if err != nil {
+			conn.Close()
+			continue
+		}
+		in := newChannelInbox(dc)
+		dc.OnOpen(func() {
+			go a.pipeConnToChannel(conn, dc, in)
+		})
+	}
+}
+
+// rejectChannel closes a remote-opened DataChannel from its OnDataChannel
+// handler. pion attaches the stream only after the handler returns, so
+// closing straight away would never reach the other side.
+func rejectChannel(dc *webrtc.DataChannel) {
+	dc.OnOpen(func() { dc.Close() })
+}
+
+// handleMappedStream serves a joiner-opened DataChannel on the host by
+// dialing the mapping's target. Only targets from the host's own table are used.
+func (a *App) handleMappedStream(dc *webrtc.DataChannel) {
+	id := strings.TrimPrefix(dc.Label(), tcpStreamLabelPrefix)
+	m, ok := a.findPortMapping(id)
+	if !ok || m.Protocol != PortProtocolTCP {
+		rejectChannel(dc)
+		return
+	}
+
+	in := newChannelInbox(dc)
+	dc.OnOpen(func() {
+		conn, err := DialTimeout("tcp", m.Target, TimeoutTCPConnect.Load())
+		if err != nil {
+			a.safeEventEmit("log", fmt.Sprintf("Error connecting to %s (%s): %v", m.Name, m.Target, err))
+			dc.Close()
+			return
+		}
+		go a.pipeConnToChannel(conn, dc, in)
+	})
+}
+
+// channelInbox queues a DataChannel's messages from the moment it is created,
+// so nothing is lost while the other side of the pipe is still being set up
+type channelInbox struct {
+	messages   chan []byte
+	closed     chan struct{}
+	overflowed bool // only touched from OnMessage, which pion calls serially
+}
+
+func newChannelInbox(dc *webrtc.DataChannel) *channelInbox {
+	in := &channelInbox{messages: make(chan []byte, channelInboxSize), closed: make(chan struct{})}
+	dc.OnMessage(func(msg webrtc.DataChannelMessage) {
+		if !in.deliver(msg.Data) {
+			// Blocking here would stall pion's SCTP read loop, and with it
+			// every other channel, so a stream that cannot keep up is closed
+			go dc.Close()
+		}
+	})
+	dc.OnClose(func() {
+		close(in.closed)
+	})
+	return in
+}
+
+// deliver queues a copy of data without blocking. It reports false once
+// when the queue overflows; later messages are dropped.
+func (in *channelInbox) deliver(data []byte) bool {
+	if in.overflowed {
+		return true
+	}
+	select {
+	case in.messages <- append([]byte(nil), data...):
+		return true
+	case <-in.closed:
+		return true
+	default:
+		in.overflowed = true
+		return false
+	}
+}
+
+// pipeConnToChannel copies bytes both ways between a TCP connection and a
+// DataChannel dedicated to it, closing each side when the other goes away
+func (a *App) pipeConnToChannel(conn net.Conn, dc *webrtc.DataChannel, in *channelInbox) {
+	a.traffic.activeStreams.Add(1)
+	defer a.traffic.activeStreams.Add(-1)
+
+	go func() {
+		defer conn.Close()
+		for {
+			select {
+			case data := <-in.messages:
+				a.traffic.addReceived(len(data))
+				if _, err := conn.Write(data); err != nil {
+					return
+				}
+			case <-in.closed:
+				return
+			}
+		}
+	}()
+
+	defer dc.Close()
+	defer conn.Close()
+
+	buf := make([]byte, 4096)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return
+		}
+		if err := dc.Send(buf[:n]); err != nil {
+			return
+		}
+		a.traffic.addSent(n)
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// connectTestApps runs the offer/answer exchange between two in-process apps
+func connectTestApps(t *testing.T, host, joiner *App) {
+	t.Helper()
+	startTestTarget(t, host)
+	offer, err := host.CreateOffer()
+	if err != nil {
+		t.Fatalf("CreateOffer failed: %v", err)
+	}
+	answer, err := joiner.AcceptOffer(offer)
+	if err != nil {
+		t.Fatalf("AcceptOffer failed: %v", err)
+	}
+	if err := host.AcceptAnswer(answer); err != nil {
+		t.Fatalf("AcceptAnswer failed: %v", err)
+	}
+	t.Cleanup(func() {
+		host.shutdown(context.Background())
+		joiner.shutdown(context.Background())
+	})
+}
+
+func freePort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to reserve port: %v", err)
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func startEchoServer(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start echo server: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				buf := make([]byte, 1024)
+				for {
+					n, err := conn.Read(buf)
+					if err != nil {
+						return
+					}
+					conn.Write(buf[:n])
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestValidatePortMappings(t *testing.T) {
+	valid := PortMapping{ID: "map", Name: "Dynmap", Protocol: PortProtocolTCP, ListenPort: 8123, Target: "localhost:8123"}
+	if err := validatePortMappings([]PortMapping{valid}, DefaultJoinerPort); err != nil {
+		t.Fatalf("Expected valid mapping, got: %v", err)
+	}
+
+	cases := map[string]PortMapping{
+		"empty id":      {Protocol: PortProtocolTCP, ListenPort: 8123, Target: "localhost:8123"},
+		"bad protocol":  {ID: "x", Protocol: "sctp", ListenPort: 8123, Target: "localhost:8123"},
+		"bad port":      {ID: "x", Protocol: PortProtocolTCP, ListenPort: 70000, Target: "localhost:8123"},
+		"reserved port": {ID: "x", Protocol: PortProtocolTCP, ListenPort: 42517, Target: "localhost:8123"},
+		"bad target":    {ID: "x", Protocol: PortProtocolTCP, ListenPort: 8123, Target: "localhost"},
+	}
+	for name, m := range cases {
+		if err := validatePortMappings([]PortMapping{m}, DefaultJoinerPort); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+
+	if err := validatePortMappings([]PortMapping{valid, valid}, DefaultJoinerPort); err == nil {
+		t.Error("Expected duplicate ids to be rejected")
+	}
+
+	// The reserved port follows the joiner port setting
+	moved := PortMapping{ID: "x", Protocol: PortProtocolTCP, ListenPort: 42517, Target: "localhost:8123"}
+	if err := validatePortMappings([]PortMapping{moved}, "40000"); err != nil {
+		t.Errorf("Expected port 42517 to be free when the joiner uses 40000, got %v", err)
+	}
+	moved.ListenPort = 40000
+	if err := validatePortMappings([]PortMapping{moved}, "40000"); err == nil {
+		t.Error("Expected the configured joiner port to be reserved")
+	}
+
+	// The joiner checks the table it receives without targets
+	valid.Target = ""
+	if err := validateMappingPorts([]PortMapping{valid}, DefaultJoinerPort); err != nil {
+		t.Errorf("Expected a mapping without target to pass the joiner's check, got %v", err)
+	}
+}
+
+func TestPortMappingForwardsTCPThroughTunnel(t *testing.T) {
+	echo := startEchoServer(t)
+	port := freePort(t)
+
+	host := &App{ctx: testContext()}
+	if err := host.SetPortMappings([]PortMapping{
+		{ID: "echo", Name: "Echo", Protocol: PortProtocolTCP, ListenPort: port, Target: echo},
+	}); err != nil {
+		t.Fatalf("SetPortMappings failed: %v", err)
+	}
+	joiner := &App{ctx: testContext()}
+	connectTestApps(t, host, joiner)
+
+	var conn net.Conn
+	var err error
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		if conn, err = net.Dial("tcp", "127.0.0.1:"+strconv.Itoa(port)); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		t.Fatalf("Joiner never listened on mapped port: %v", err)
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Write([]byte("hello tunnel\n")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil || line != "hello tunnel\n" {
+		t.Fatalf("Expected echo through tunnel, got %q (%v)", line, err)
+	}
+
+	// The joiner learns ids and ports, never the host's targets
+	for _, m := range joiner.GetPortMappings() {
+		if m.Target != "" {
+			t.Errorf("Expected no target on the joiner, got %q", m.Target)
+		}
+	}
+}
+
+func TestChannelInboxClosesOnOverflow(t *testing.T) {
+	in := &channelInbox{messages: make(chan []byte, channelInboxSize), closed: make(chan struct{})}
+	for i := 0; i < channelInboxSize; i++ {
+		if !in.deliver([]byte("x")) {
+			t.Fatalf("Expected message %d to be queued", i)
+		}
+	}
+	if in.deliver([]byte("x")) {
+		t.Fatal("Expected a full inbox to report overflow instead of blocking")
+	}
+	if !in.deliver([]byte("x")) {
+		t.Error("Expected overflow to be reported only once")
+	}
+}
+
+func TestMappedStreamRejectsProtocolMismatch(t *testing.T) {
+	host := &App{ctx: testContext()}
+	if err := host.SetPortMappings([]PortMapping{
+		{ID: "voice", Name: "Voice", Protocol: PortProtocolUDP, ListenPort: freePort(t), Target: "localhost:24454"},
+	}); err != nil {
+		t.Fatalf("SetPortMappings failed: %v", err)
+	}
+	joiner := &App{ctx: testContext()}
+	connectTestApps(t, host, joiner)
+
+	dc, err := joiner.peerConnection.CreateDataChannel(tcpStreamLabelPrefix+"voice", nil)
+	if err != nil {
+		t.Fatalf("CreateDataChannel failed: %v", err)
+	}
+	closed := make(chan struct{})
+	dc.OnClose(func() { close(closed) })
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the host to close a TCP stream for a UDP mapping")
+	}
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// ProfilesVersion is the profiles file layout; older files are brought
+	// up to date by migrateProfiles, like settings by migrateSettings
+	ProfilesVersion  = 1
+	ProfilesFileName = "profiles.json"
+
+	ProfileRoleHost = "host"
+	ProfileRoleJoin = "join"
+
+	maxProfileNameLength = 64
+)
+
+// Profile is a saved tunnel setup for one server or friend group
+type Profile struct {
+	ID            string   `json:"id"`
+	Name          string   `json:"name"`
+	Role          string   `json:"role"`
+	TargetAddress string   `json:"targetAddress,omitempty"`
+	ListenPort    int      `json:"listenPort,omitempty"`
+	ICEServers    []string `json:"iceServers"`
+	// PassphraseRef names a secret kept outside the profile (e.g. a keychain
+	// entry); the passphrase itself is never written to profiles.json
+	PassphraseRef string `json:"passphraseRef,omitempty"`
+}
+
+type profilesFile struct {
+	Version  int       `json:"version"`
+	Profiles []Profile `json:"profiles"`
+}
+
+func validateProfile(p Profile) error {
+	name := strings.TrimSpace(p.Name)
+	if name == "" {
+		return fmt.Errorf("profile name is required")
+	}
+	if utf8.RuneCountInString(name) > maxProfileNameLength {
+		return fmt.Errorf("profile name is longer than %d characters", maxProfileNameLength)
+	}
+	switch p.Role {
+	case ProfileRoleHost:
+		if _, _, err := net.SplitHostPort(p.TargetAddress); err != nil {
+			return fmt.Errorf("invalid server address %q: %w", p.TargetAddress, err)
+		}
+	case ProfileRoleJoin:
+		if p.ListenPort <= 0 || p.ListenPort > 65535 {
+			return fmt.Errorf("invalid listen port %d", p.ListenPort)
+		}
+	default:
+		return fmt.Errorf("profile role must be %q or %q, got %q", ProfileRoleHost, ProfileRoleJoin, p.Role)
+	}
+	for _, url := range p.ICEServers {
+		if !strings.HasPrefix(url, "stun:") && !strings.HasPrefix(url, "stuns:") {
+			return fmt.Errorf("invalid ICE server %q: must start with stun: or stuns:", url)
+		}
+	}
+	return nil
+}
+
+// migrateProfiles upgrades a profiles file written by an older version in
+// place, one version step at a time
+func migrateProfiles(f *profilesFile) error {
+	if f.Version > ProfilesVersion {
+		return fmt.Errorf("profiles were written by a newer version (%d)", f.Version)
+	}
+	return nil
+}
+
+func newProfileID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (a *App) profilesPath() (string, error) {
+	return a.configFile(ProfilesFileName)
+}
+
+// readProfiles loads the saved profiles, or none if the file does not exist yet
+func (a *App) readProfiles() ([]Profile, error) {
+	path, err := a.profilesPath()
+	if err != nil {
+		return nil, fmt.Errorf("no profiles directory: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f profilesFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid profiles file: %w", err)
+	}
+	if err := migrateProfiles(&f); err != nil {
+		return nil, err
+	}
+	return f.Profiles, nil
+}
+
+func (a *App) writeProfiles(profiles []Profile) error {
+	path, err := a.profilesPath()
+	if err != nil {
+		return fmt.Errorf("no profiles directory: %w", err)
+	}
+	if profiles == nil {
+		profiles = []Profile{}
+	}
+	if err := writeConfigJSON(path, profilesFile{Version: ProfilesVersion, Profiles: profiles}); err != nil {
+		return fmt.Errorf("failed to save profiles: %w", err)
+	}
+	return nil
+}
+
+// ListProfiles returns the saved profiles in creation order
+func (a *App) ListProfiles() ([]Profile, error) {
+	a.profilesMu.Lock()
+	defer a.profilesMu.Unlock()
+	profiles, err := a.readProfiles()
+	if profiles == nil {
+		profiles = []Profile{}
+	}
+	return profiles, err
+}
+
+// CreateProfile saves a new profile and returns it with its assigned ID
+func (a *App) CreateProfile(p Profile) (Profile, error) {
+	p.Name = strings.TrimSpace(p.Name)
+	if err := validateProfile(p); err != nil {
+		return Profile{}, err
+	}
+	a.profilesMu.Lock()
+	defer a.profilesMu.Unlock()
+	profiles, err := a.readProfiles()
+	if err != nil {
+		return Profile{}, err
+	}
+	p.ID = newProfileID()
+	if err := a.writeProfiles(append(profiles, p)); err != nil {
+		return Profile{}, err
+	}
+	return p, nil
+}
+
+// DuplicateProfile copies a profile under a new ID, named "<name> (copy)"
+func (a *App) DuplicateProfile(id string) (Profile, error) {
+	a.profilesMu.Lock()
+	defer a.profilesMu.Unlock()
+	profiles, err := a.readProfiles()
+	if err != nil {
+		return Profile{}, err
+	}
+	for _, p := range profiles {
+		if p.ID != id {
+			continue
+		}
+		dup := p
+		dup.ID = newProfileID()
+		dup.Name = truncateRunes(p.Name+" (copy)", maxProfileNameLength)
+		dup.ICEServers = append([]string(nil), p.ICEServers...)
+		if err := a.writeProfiles(append(profiles, dup)); err != nil {
+			return Profile{}, err
+		}
+		return dup, nil
+	}
+	return Profile{}, fmt.Errorf("no profile with id %q", id)
+}
+
+// DeleteProfile removes a saved profile
+func (a *App) DeleteProfile(id string) error {
+	a.profilesMu.Lock()
+	defer a.profilesMu.Unlock()
+	profiles, err := a.readProfiles()
+	if err != nil {
+		return err
+	}
+	for i, p := range profiles {
+		if p.ID == id {
+			return a.writeProfiles(append(profiles[:i], profiles[i+1:]...))
+		}
+	}
+	return fmt.Errorf("no profile with id %q", id)
+}
+
+// selectProfile makes a saved profile drive the next session. Host profiles
+// set the target address; the ICE servers and listen port are read through
+// iceServers and joinerPort while the profile is active.
+func (a *App) selectProfile(id, role string) error {
+	profiles, err := a.ListProfiles()
+	if err != nil {
+		return err
+	}
+	for _, p := range profiles {
+		if p.ID != id {
+			continue
+		}
+		if p.Role != role {
+			return fmt.Errorf("profile %q is a %s profile", p.Name, p.Role)
+		}
+		a.setActiveProfile(&p)
+		return nil
+	}
+	return fmt.Errorf("no profile with id %q", id)
+}
+
+func (a *App) setActiveProfile(p *Profile) {
+	a.settingsMu.Lock()
+	a.activeProfile = p
+	a.settingsMu.Unlock()
+}
+
+// GetActiveProfile returns the profile the current session started with, if any
+func (a *App) GetActiveProfile() *Profile {
+	a.settingsMu.Lock()
+	defer a.settingsMu.Unlock()
+	if a.activeProfile == nil {
+		return nil
+	}
+	p := *a.activeProfile
+	return &p
+}
+
+// CreateOfferWithProfile starts hosting with a saved host profile
+func (a *App) CreateOfferWithProfile(id string) (string, error) {
+	if err := a.selectProfile(id, ProfileRoleHost); err != nil {
+		return "", err
+	}
+	return a.createOffer()
+}
+
+// AcceptOfferWithProfile joins with a saved join profile
+func (a *App) AcceptOfferWithProfile(id, offerToken string) (string, error) {
+	if err := a.selectProfile(id, ProfileRoleJoin); err != nil {
+		return "", err
+	}
+	return a.acceptOffer(offerToken)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestProfileCRUD(t *testing.T) {
+	app := &App{ctx: testContext(), configDir: t.TempDir()}
+
+	host, err := app.CreateProfile(Profile{Name: " Survival ", Role: ProfileRoleHost, TargetAddress: "localhost:25566", PassphraseRef: "keychain:survival"})
+	if err != nil {
+		t.Fatalf("CreateProfile failed: %v", err)
+	}
+	if host.ID == "" || host.Name != "Survival" {
+		t.Fatalf("Expected an ID and trimmed name, got %+v", host)
+	}
+	dup, err := app.DuplicateProfile(host.ID)
+	if err != nil {
+		t.Fatalf("DuplicateProfile failed: %v", err)
+	}
+	if dup.ID == host.ID || dup.Name != "Survival (copy)" || dup.TargetAddress != host.TargetAddress {
+		t.Errorf("Unexpected duplicate %+v", dup)
+	}
+
+	// A restarted app sees the same profiles
+	restarted := &App{ctx: testContext(), configDir: app.configDir}
+	profiles, err := restarted.ListProfiles()
+	if err != nil {
+		t.Fatalf("ListProfiles failed: %v", err)
+	}
+	if !reflect.DeepEqual(profiles, []Profile{host, dup}) {
+		t.Fatalf("Expected both profiles, got %+v", profiles)
+	}
+
+	if err := app.DeleteProfile(host.ID); err != nil {
+		t.Fatalf("DeleteProfile failed: %v", err)
+	}
+	if err := app.DeleteProfile(host.ID); err == nil {
+		t.Error("Expected error deleting a missing profile")
+	}
+	profiles, _ = app.ListProfiles()
+	if len(profiles) != 1 || profiles[0].ID != dup.ID {
+		t.Errorf("Expected only the duplicate left, got %+v", profiles)
+	}
+}
+
+func TestCreateProfileValidates(t *testing.T) {
+	app := &App{ctx: testContext(), configDir: t.TempDir()}
+	bad := map[string]Profile{
+		"no name":     {Role: ProfileRoleJoin, ListenPort: 40000},
+		"bad role":    {Name: "x", Role: "relay"},
+		"bad target":  {Name: "x", Role: ProfileRoleHost, TargetAddress: "localhost"},
+		"bad port":    {Name: "x", Role: ProfileRoleJoin},
+		"turn server": {Name: "x", Role: ProfileRoleJoin, ListenPort: 40000, ICEServers: []string{"turn:relay.example.com"}},
+	}
+	for name, p := range bad {
+		if _, err := app.CreateProfile(p); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+}
+
+func TestSelectProfileOverridesSettings(t *testing.T) {
+	app := &App{ctx: testContext(), configDir: t.TempDir()}
+	app.applySettings(DefaultSettings())
+	join, _ := app.CreateProfile(Profile{Name: "Creative", Role: ProfileRoleJoin, ListenPort: 40002, ICEServers: []string{"stun:relay.example.com:3478"}})
+	host, _ := app.CreateProfile(Profile{Name: "Modded", Role: ProfileRoleHost, TargetAddress: "localhost:25570"})
+
+	if err := app.selectProfile(join.ID, ProfileRoleHost); err == nil {
+		t.Fatal("Expected error hosting with a join profile")
+	}
+	if err := app.selectProfile(join.ID, ProfileRoleJoin); err != nil {
+		t.Fatalf("selectProfile failed: %v", err)
+	}
+	if app.joinerPort() != "40002" || app.iceServers()[0].URLs[0] != "stun:relay.example.com:3478" {
+		t.Errorf("Expected the profile's port and ICE server, got %s %v", app.joinerPort(), app.iceServers())
+	}
+
+	if err := app.selectProfile(host.ID, ProfileRoleHost); err != nil {
+		t.Fatalf("selectProfile failed: %v", err)
+	}
+	if app.target() != "localhost:25570" || app.iceServers()[0].URLs[0] != DefaultICEServer {
+		t.Errorf("Expected the profile's target and default ICE server, got %s %v", app.target(), app.iceServers())
+	}
+
+	app.setActiveProfile(nil)
+	if app.joinerPort() != DefaultJoinerPort {
+		t.Errorf("Expected the settings port without a profile, got %s", app.joinerPort())
+	}
+	if app.target() != DefaultTargetAddress {
+		t.Errorf("Expected the profile's target to end with the profile, got %s", app.target())
+	}
+}
+
+func TestReadProfilesRefusesNewerFiles(t *testing.T) {
+	app := &App{ctx: testContext(), configDir: t.TempDir()}
+	path := filepath.Join(app.configDir, ProfilesFileName)
+
+	if err := os.WriteFile(path, []byte(`{"version":99,"profiles":[]}`), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if _, err := app.ListProfiles(); err == nil {
+		t.Error("Expected a file from a newer version to be refused")
+	}
+}
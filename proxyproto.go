@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+)
+
+// HAProxy PROXY protocol v2, see
+// https://www.haproxy.org/download/2.9/doc/proxy-protocol.txt
+
+var proxyV2Signature = []byte{0x0D, 0x0A, 0x0D, 0x0A, 0x00, 0x0D, 0x0A, 0x51, 0x55, 0x49, 0x54, 0x0A}
+
+const (
+	proxyV2CommandLocal = 0x20
+	proxyV2CommandProxy = 0x21
+
+	proxyV2FamilyUnspec  = 0x00
+	proxyV2FamilyTCPIPv4 = 0x11
+	proxyV2FamilyTCPIPv6 = 0x21
+
+	// First TLV type reserved for application-specific data
+	proxyV2TypePeerID = 0xE0
+)
+
+// proxyV2Header builds a PROXY protocol v2 header. When src or dst is nil the
+// LOCAL command is used so the receiver keeps the real connection addresses.
+// A non-empty peerID is attached as a custom TLV.
+func proxyV2Header(src, dst *net.TCPAddr, peerID string) []byte {
+	var addrs []byte
+	command := byte(proxyV2CommandLocal)
+	family := byte(proxyV2FamilyUnspec)
+
+	if src != nil && dst != nil {
+		command = proxyV2CommandProxy
+		if src4, dst4 := src.IP.To4(), dst.IP.To4(); src4 != nil && dst4 != nil {
+			family = proxyV2FamilyTCPIPv4
+			addrs = append(addrs, src4...)
+			addrs = append(addrs, dst4...)
+		} else {
+			family = proxyV2FamilyTCPIPv6
+			addrs = append(addrs, src.IP.To16()...)
+			addrs = append(addrs, dst.IP.To16()...)
+		}
+		addrs = binary.BigEndian.AppendUint16(addrs, uint16(src.Port))
+		addrs = binary.BigEndian.AppendUint16(addrs, uint16(dst.Port))
+	}
+
+	if peerID != "" {
+		addrs = append(addrs, proxyV2TypePeerID)
+		addrs = binary.BigEndian.AppendUint16(addrs, uint16(len(peerID)))
+		addrs = append(addrs, peerID...)
+	}
+
+	var buf bytes.Buffer
+	buf.Write(proxyV2Signature)
+	buf.WriteByte(command)
+	buf.WriteByte(family)
+	binary.Write(&buf, binary.BigEndian, uint16(len(addrs)))
+	buf.Write(addrs)
+	return buf.Bytes()
+}
+
+// tunnelProxyHeader describes the joiner as seen by ICE: the remote candidate
+// is the source and our local candidate the destination
+func (a *App) tunnelProxyHeader() []byte {
+	var src, dst *net.TCPAddr
+	if pair := a.selectedCandidatePair(); pair != nil {
+		remoteIP, localIP := net.ParseIP(pair.Remote.Address), net.ParseIP(pair.Local.Address)
+		if remoteIP != nil && localIP != nil {
+			src = &net.TCPAddr{IP: remoteIP, Port: int(pair.Remote.Port)}
+			dst = &net.TCPAddr{IP: localIP, Port: int(pair.Local.Port)}
+		}
+	}
+	return proxyV2Header(src, dst, a.remotePeerFingerprint())
+}
+
+// SetProxyProtocol toggles sending a PROXY protocol v2 header on each
+// connection to the Minecraft server (for Velocity, BungeeCord, Paper etc.)
+// and saves the choice in the settings
+func (a *App) SetProxyProtocol(enabled bool) error {
+	a.proxyProtocol.Store(enabled)
+	return a.saveSettingsChange(func(s *Settings) { s.Security.ProxyProtocol = enabled })
+}
+
+// GetProxyProtocol reports whether PROXY protocol headers are sent
+func (a *App) GetProxyProtocol() bool {
+	return a.proxyProtocol.Load()
+}
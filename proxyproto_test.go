@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+)
+
+func TestProxyV2HeaderIPv4(t *testing.T) {
+	src := &net.TCPAddr{IP: net.ParseIP("203.0.113.7"), Port: 50000}
+	dst := &net.TCPAddr{IP: net.ParseIP("192.168.1.10"), Port: 25565}
+	h := proxyV2Header(src, dst, "")
+
+	if !bytes.Equal(h[:12], proxyV2Signature) {
+		t.Fatal("Missing PROXY v2 signature")
+	}
+	if h[12] != proxyV2CommandProxy || h[13] != proxyV2FamilyTCPIPv4 {
+		t.Fatalf("Unexpected command/family: %#x %#x", h[12], h[13])
+	}
+	if binary.BigEndian.Uint16(h[14:16]) != 12 || len(h) != 28 {
+		t.Fatalf("Expected 12 address bytes, header is %d bytes", len(h))
+	}
+	if !net.IP(h[16:20]).Equal(src.IP) || !net.IP(h[20:24]).Equal(dst.IP) {
+		t.Fatal("Unexpected addresses")
+	}
+	if binary.BigEndian.Uint16(h[24:26]) != 50000 || binary.BigEndian.Uint16(h[26:28]) != 25565 {
+		t.Fatal("Unexpected ports")
+	}
+}
+
+func TestProxyV2HeaderIPv6WithPeerID(t *testing.T) {
+	src := &net.TCPAddr{IP: net.ParseIP("2001:db8::1"), Port: 1}
+	dst := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 2}
+	h := proxyV2Header(src, dst, "sha-256 AB:CD")
+
+	if h[13] != proxyV2FamilyTCPIPv6 {
+		t.Fatalf("Expected IPv6 family for mixed addresses, got %#x", h[13])
+	}
+	tlv := h[16+36:]
+	if tlv[0] != proxyV2TypePeerID || binary.BigEndian.Uint16(tlv[1:3]) != 13 || string(tlv[3:]) != "sha-256 AB:CD" {
+		t.Fatalf("Unexpected peer ID TLV: %x", tlv)
+	}
+	if int(binary.BigEndian.Uint16(h[14:16])) != len(h)-16 {
+		t.Fatal("Header length does not cover addresses and TLVs")
+	}
+}
+
+func TestProxyV2HeaderLocalWithoutAddresses(t *testing.T) {
+	h := proxyV2Header(nil, nil, "")
+	if h[12] != proxyV2CommandLocal || h[13] != proxyV2FamilyUnspec || len(h) != 16 {
+		t.Fatalf("Unexpected LOCAL header: %x", h)
+	}
+}
+
+func TestHostStreamSendsProxyHeaderFirst(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer ln.Close()
+
+	app := &App{ctx: testContext()}
+	app.SetProxyProtocol(true)
+	stream := app.newHostStream(&webrtc.DataChannel{}, ln.Addr().String())
+	defer stream.close()
+
+	login := loginStream(765, "Alex", testPlayerUUID)
+	stream.fromTunnel(login)
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("Accept failed: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+
+	want := append(proxyV2Header(nil, nil, ""), login...)
+	got := make([]byte, len(want))
+	if _, err := io.ReadFull(conn, got); err != nil || !bytes.Equal(got, want) {
+		t.Fatalf("Expected PROXY header followed by login, got %x (%v)", got, err)
+	}
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// Source RCON as implemented by the Minecraft server, see
+// https://minecraft.wiki/w/RCON
+
+const DefaultRCONAddress = "localhost:25575"
+
+const (
+	rconTypeResponse = 0
+	rconTypeCommand  = 2
+	rconTypeLogin    = 3
+
+	// Requests are limited to 1446 bytes of payload by the server
+	maxRCONCommandLength = 1446
+	maxRCONPacketLength  = 4096 + 10
+)
+
+var errRCONAuth = errors.New("RCON authentication failed: wrong password")
+
+// RCONConfig is the host's RCON connection settings
+type RCONConfig struct {
+	Address  string `json:"address"`
+	Password string `json:"password"`
+}
+
+func encodeRCONPacket(id, kind int32, body string) []byte {
+	buf := binary.LittleEndian.AppendUint32(nil, uint32(4+4+len(body)+2))
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(id))
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(kind))
+	buf = append(buf, body...)
+	return append(buf, 0, 0)
+}
+
+func readRCONPacket(r io.Reader) (id, kind int32, body string, err error) {
+	var length int32
+	if err = binary.Read(r, binary.LittleEndian, &length); err != nil {
+		return
+	}
+	if length < 10 || length > maxRCONPacketLength {
+		err = fmt.Errorf("invalid RCON packet length %d", length)
+		return
+	}
+	data := make([]byte, length)
+	if _, err = io.ReadFull(r, data); err != nil {
+		return
+	}
+	id = int32(binary.LittleEndian.Uint32(data[0:4]))
+	kind = int32(binary.LittleEndian.Uint32(data[4:8]))
+	body = string(bytes.TrimRight(data[8:], "\x00"))
+	return
+}
+
+// rconClient is an authenticated RCON connection. Commands are serialised.
+type rconClient struct {
+	mu     sync.Mutex
+	conn   net.Conn
+	r      *bufio.Reader
+	nextID int32
+}
+
+func dialRCON(address, password string, timeout time.Duration) (*rconClient, error) {
+	conn, err := DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return nil, err
+	}
+	c := &rconClient{conn: conn, r: bufio.NewReader(conn)}
+
+	conn.SetDeadline(time.Now().Add(timeout))
+	id, err := c.request(rconTypeLogin, password)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if id == -1 {
+		conn.Close()
+		return nil, errRCONAuth
+	}
+	return c, nil
+}
+
+// request sends one packet and returns the id of the reply, whose body is
+// discarded for logins
+func (c *rconClient) request(kind int32, body string) (int32, error) {
+	c.nextID++
+	if _, err := c.conn.Write(encodeRCONPacket(c.nextID, kind, body)); err != nil {
+		return 0, err
+	}
+	id, _, _, err := readRCONPacket(c.r)
+	return id, err
+}
+
+func (c *rconClient) execute(command string, timeout time.Duration) (string, error) {
+	if len(command) > maxRCONCommandLength {
+		return "", fmt.Errorf("command is longer than %d bytes", maxRCONCommandLength)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.conn.SetDeadline(time.Now().Add(timeout))
+	c.nextID++
+	want := c.nextID
+	if _, err := c.conn.Write(encodeRCONPacket(want, rconTypeCommand, command)); err != nil {
+		return "", err
+	}
+	for {
+		id, kind, body, err := readRCONPacket(c.r)
+		if err != nil {
+			return "", err
+		}
+		if id == want && kind == rconTypeResponse {
+			return body, nil
+		}
+	}
+}
+
+func (c *rconClient) Close() error {
+	return c.conn.Close()
+}
+
+// SetRCONConfig sets the RCON address and password used by RunRCONCommand
+func (a *App) SetRCONConfig(config RCONConfig) error {
+	if config.Address == "" {
+		config.Address = DefaultRCONAddress
+	}
+	if _, _, err := net.SplitHostPort(config.Address); err != nil {
+		return fmt.Errorf("invalid RCON address %q: %w", config.Address, err)
+	}
+
+	a.rconMu.Lock()
+	defer a.rconMu.Unlock()
+	a.rconConfig = config
+	if a.rcon != nil {
+		a.rcon.Close()
+		a.rcon = nil
+	}
+	return nil
+}
+
+// GetRCONConfig returns the current RCON settings
+func (a *App) GetRCONConfig() RCONConfig {
+	a.rconMu.Lock()
+	defer a.rconMu.Unlock()
+	config := a.rconConfig
+	if config.Address == "" {
+		config.Address = DefaultRCONAddress
+	}
+	return config
+}
+
+// RunRCONCommand runs a server console command such as "whitelist add Steve"
+// and returns the server's reply. The connection is opened on first use and
+// reopened once if it has dropped.
+func (a *App) RunRCONCommand(command string) (string, error) {
+	config := a.GetRCONConfig()
+	if config.Password == "" {
+		return "", fmt.Errorf("RCON password is not set")
+	}
+
+	for attempt := 0; ; attempt++ {
+		a.rconMu.Lock()
+		client := a.rcon
+		a.rconMu.Unlock()
+
+		if client == nil {
+			var err error
+			client, err = dialRCON(config.Address, config.Password, TimeoutTCPConnect.Load())
+			if err != nil {
+				return "", fmt.Errorf("failed to connect to RCON at %s: %w", config.Address, err)
+			}
+			a.rconMu.Lock()
+			if a.rcon != nil {
+				a.rcon.Close()
+			}
+			a.rcon = client
+			a.rconMu.Unlock()
+		}
+
+		reply, err := client.execute(command, TimeoutTCPOperation.Load())
+		if err == nil {
+			return reply, nil
+		}
+
+		a.rconMu.Lock()
+		if a.rcon == client {
+			a.rcon = nil
+		}
+		a.rconMu.Unlock()
+		client.Close()
+		if attempt > 0 {
+			return "", fmt.Errorf("RCON command failed: %w", err)
+		}
+	}
+}
+
+// RunRemoteRCONCommand asks the host to run a command on its server. The
+// host must have granted us RCON access with SetPeerRCONAccess.
+func (a *App) RunRemoteRCONCommand(command string) (string, error) {
+	a.controlMu.Lock()
+	s := a.control
+	a.controlMu.Unlock()
+	if s == nil || s.negotiated() == 0 {
+		return "", fmt.Errorf("not connected to a peer")
+	}
+	if !s.hasPeerFeature("rcon") {
+		return "", fmt.Errorf("host does not support remote RCON")
+	}
+
+	nonce := time.Now().UnixNano()
+	reply := make(chan controlMessage, 1)
+	a.rconMu.Lock()
+	if a.rconPending == nil {
+		a.rconPending = make(map[int64]chan controlMessage)
+	}
+	a.rconPending[nonce] = reply
+	a.rconMu.Unlock()
+	defer func() {
+		a.rconMu.Lock()
+		delete(a.rconPending, nonce)
+		a.rconMu.Unlock()
+	}()
+
+	if err := s.send(controlMessage{Type: controlTypeRCON, Nonce: nonce, Text: command}); err != nil {
+		return "", err
+	}
+
+	select {
+	case m := <-reply:
+		if m.Reason != "" {
+			return "", errors.New(m.Reason)
+		}
+		return m.Text, nil
+	case <-time.After(TimeoutNetwork):
+		return "", fmt.Errorf("host did not answer within %v", TimeoutNetwork)
+	}
+}
+
+// serveRemoteRCON runs a joiner's command on the host if the host granted
+// RCON access to the joiner's certificate fingerprint
+func (a *App) serveRemoteRCON(s *controlSession, m controlMessage) {
+	result := controlMessage{Type: controlTypeRCONResult, Nonce: m.Nonce}
+	if !a.peerAllowsRCON(a.remotePeerFingerprint()) {
+		result.Reason = "The host has not granted you RCON access"
+	} else {
+		a.safeEventEmit("log", fmt.Sprintf("Joiner ran RCON command: %s", m.Text))
+		reply, err := a.RunRCONCommand(m.Text)
+		if err != nil {
+			result.Reason = err.Error()
+		}
+		result.Text = reply
+	}
+	s.send(result)
+}
+
+// deliverRCONResult hands the host's answer to the waiting RunRemoteRCONCommand
+func (a *App) deliverRCONResult(m controlMessage) {
+	a.rconMu.Lock()
+	reply, ok := a.rconPending[m.Nonce]
+	a.rconMu.Unlock()
+	if ok {
+		select {
+		case reply <- m:
+		default:
+		}
+	}
+}
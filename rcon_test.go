@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startFakeRCONServer answers logins with password and echoes commands back
+// as "ran: <command>". Each connection is closed after maxCommands commands.
+func startFakeRCONServer(t *testing.T, password string, maxCommands int) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start RCON server: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				commands := 0
+				for {
+					id, kind, body, err := readRCONPacket(r)
+					if err != nil {
+						return
+					}
+					switch kind {
+					case rconTypeLogin:
+						if body != password {
+							id = -1
+						}
+						conn.Write(encodeRCONPacket(id, rconTypeCommand, ""))
+					case rconTypeCommand:
+						conn.Write(encodeRCONPacket(id, rconTypeResponse, "ran: "+body))
+						if commands++; commands == maxCommands {
+							return
+						}
+					}
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestRCONPacketRoundTrip(t *testing.T) {
+	id, kind, body, err := readRCONPacket(bytes.NewReader(encodeRCONPacket(7, rconTypeCommand, "list")))
+	if err != nil || id != 7 || kind != rconTypeCommand || body != "list" {
+		t.Fatalf("Expected packet 7/2/list, got %d/%d/%q (%v)", id, kind, body, err)
+	}
+}
+
+func TestRunRCONCommand(t *testing.T) {
+	app := &App{ctx: testContext()}
+	if err := app.SetRCONConfig(RCONConfig{Address: startFakeRCONServer(t, "secret", 0), Password: "secret"}); err != nil {
+		t.Fatalf("SetRCONConfig failed: %v", err)
+	}
+	defer app.shutdown(testContext())
+
+	reply, err := app.RunRCONCommand("say hi")
+	if err != nil || reply != "ran: say hi" {
+		t.Fatalf("Expected echoed command, got %q (%v)", reply, err)
+	}
+}
+
+func TestRunRCONCommandRejectsWrongPassword(t *testing.T) {
+	app := &App{ctx: testContext()}
+	app.SetRCONConfig(RCONConfig{Address: startFakeRCONServer(t, "secret", 0), Password: "wrong"})
+
+	if _, err := app.RunRCONCommand("list"); !errors.Is(err, errRCONAuth) {
+		t.Fatalf("Expected auth error, got %v", err)
+	}
+}
+
+func TestRunRCONCommandReconnects(t *testing.T) {
+	app := &App{ctx: testContext()}
+	app.SetRCONConfig(RCONConfig{Address: startFakeRCONServer(t, "secret", 1), Password: "secret"})
+	defer app.shutdown(testContext())
+
+	for _, cmd := range []string{"list", "time set day"} {
+		if reply, err := app.RunRCONCommand(cmd); err != nil || reply != "ran: "+cmd {
+			t.Fatalf("Expected %q to run after reconnect, got %q (%v)", cmd, reply, err)
+		}
+	}
+}
+
+func TestRemoteRCONRequiresPeerGrant(t *testing.T) {
+	server := startFakeRCONServer(t, "secret", 0)
+	host := &App{ctx: testContext(), configDir: t.TempDir()}
+	host.SetRCONConfig(RCONConfig{Address: server, Password: "secret"})
+	joiner := &App{ctx: testContext(), configDir: t.TempDir()}
+	connectTestApps(t, host, joiner)
+
+	if !waitFor(t, 5*time.Second, func() bool {
+		peers, _ := host.ListKnownPeers()
+		return len(peers) == 1
+	}) {
+		t.Fatal("Host never remembered the joiner")
+	}
+
+	if _, err := joiner.RunRemoteRCONCommand("op Steve"); err == nil || !strings.Contains(err.Error(), "not granted") {
+		t.Fatalf("Expected refusal without grant, got %v", err)
+	}
+	if err := host.SetPeerRCONAccess("sha-256 00:11", true); err == nil {
+		t.Error("Expected granting an unknown peer to fail")
+	}
+
+	fingerprint := host.remotePeerFingerprint()
+	if err := host.SetPeerRCONAccess(fingerprint, true); err != nil {
+		t.Fatalf("SetPeerRCONAccess failed: %v", err)
+	}
+	reply, err := joiner.RunRemoteRCONCommand("say from joiner")
+	if err != nil || reply != "ran: say from joiner" {
+		t.Fatalf("Expected command to run on host, got %q (%v)", reply, err)
+	}
+
+	host.SetPeerRCONAccess(fingerprint, false)
+	if _, err := joiner.RunRemoteRCONCommand("op Steve"); err == nil {
+		t.Fatal("Expected refusal after the grant was revoked")
+	}
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	ServerStateStopped  = "stopped"
+	ServerStateStarting = "starting"
+	ServerStateReady    = "ready"
+	ServerStateStopping = "stopping"
+
+	// How long a server gets to save worlds after "stop" before it is killed
+	ServerStopTimeout = 30 * time.Second
+
+	DefaultJavaPath = "java"
+
+	// Longest console line forwarded; mods can log stack traces or data
+	// dumps well past bufio's 64 KiB default
+	maxConsoleLineSize = 1024 * 1024
+)
+
+// Logged by the server once worlds are loaded, e.g.
+// [12:00:00] [Server thread/INFO]: Done (3.142s)! For help, type "help"
+var serverDonePattern = regexp.MustCompile(`Done \([0-9.,]+s\)!`)
+
+// ServerLaunchConfig describes how to start the Minecraft server
+type ServerLaunchConfig struct {
+	JavaPath   string   `json:"javaPath"`
+	JarPath    string   `json:"jarPath"`
+	MinMemory  string   `json:"minMemory"`
+	MaxMemory  string   `json:"maxMemory"`
+	ExtraArgs  []string `json:"extraArgs"`
+	WorkingDir string   `json:"workingDir"`
+}
+
+// serverProcess is a running server and its stdin
+type serverProcess struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	exited chan struct{}
+}
+
+// serverCommand builds the java command line and working directory
+func serverCommand(config ServerLaunchConfig) ([]string, string, error) {
+	if config.JarPath == "" {
+		return nil, "", fmt.Errorf("server jar is not set")
+	}
+	java := config.JavaPath
+	if java == "" {
+		java = DefaultJavaPath
+	}
+
+	args := []string{java}
+	if config.MinMemory != "" {
+		args = append(args, "-Xms"+config.MinMemory)
+	}
+	if config.MaxMemory != "" {
+		args = append(args, "-Xmx"+config.MaxMemory)
+	}
+	args = append(args, config.ExtraArgs...)
+	args = append(args, "-jar", config.JarPath, "nogui")
+
+	dir := config.WorkingDir
+	if dir == "" {
+		dir = filepath.Dir(config.JarPath)
+	}
+	return args, dir, nil
+}
+
+func (a *App) setServerState(state string) {
+	a.serverProcMu.Lock()
+	a.serverState = state
+	a.serverProcMu.Unlock()
+	a.safeEventEmit("server-state", state)
+}
+
+// StartServer launches the Minecraft server and streams its console as
+// "server-console" events. The state becomes ready when the server logs "Done".
+// A config that starts is saved so the host screen can offer it next time.
+func (a *App) StartServer(config ServerLaunchConfig) error {
+	args, dir, err := serverCommand(config)
+	if err != nil {
+		return err
+	}
+
+	a.serverProcMu.Lock()
+	defer a.serverProcMu.Unlock()
+	if a.serverProc != nil {
+		return fmt.Errorf("server is already running")
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = dir
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	output, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	cmd.Stderr = cmd.Stdout
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start server: %w", err)
+	}
+
+	proc := &serverProcess{cmd: cmd, stdin: stdin, exited: make(chan struct{})}
+	a.serverProc = proc
+	a.serverState = ServerStateStarting
+	a.safeEventEmit("server-state", ServerStateStarting)
+	a.safeEventEmit("log", fmt.Sprintf("Starting Minecraft server: %s", strings.Join(args, " ")))
+
+	go a.superviseServer(proc, output)
+
+	if err := a.saveSettingsChange(func(s *Settings) { s.Server = config }); err != nil {
+		a.safeEventEmit("log", fmt.Sprintf("Failed to save the server launch config: %v", err))
+	}
+	return nil
+}
+
+// superviseServer forwards console lines until the process exits
+func (a *App) superviseServer(proc *serverProcess, output io.Reader) {
+	scanner := bufio.NewScanner(output)
+	scanner.Buffer(make([]byte, 64*1024), maxConsoleLineSize)
+	for scanner.Scan() {
+		line := scanner.Text()
+		a.safeEventEmit("server-console", line)
+		if serverDonePattern.MatchString(line) && a.GetServerState() == ServerStateStarting {
+			a.setServerState(ServerStateReady)
+			a.safeEventEmit("log", "Minecraft server is ready")
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		// Keep draining so the server never blocks on a full pipe
+		a.safeEventEmit("log", fmt.Sprintf("Minecraft server console no longer shown: %v", err))
+		io.Copy(io.Discard, output)
+	}
+
+	err := proc.cmd.Wait()
+
+	// stopServer returns once exited is closed, so the state must already
+	// read stopped by then
+	a.serverProcMu.Lock()
+	if a.serverProc == proc {
+		a.serverProc = nil
+	}
+	a.serverProcMu.Unlock()
+	a.setServerState(ServerStateStopped)
+	close(proc.exited)
+
+	if err != nil {
+		a.safeEventEmit("log", fmt.Sprintf("Minecraft server exited: %v", err))
+	} else {
+		a.safeEventEmit("log", "Minecraft server stopped")
+	}
+}
+
+// SendServerCommand writes a console command to the server's stdin
+func (a *App) SendServerCommand(command string) error {
+	a.serverProcMu.Lock()
+	proc := a.serverProc
+	a.serverProcMu.Unlock()
+	if proc == nil {
+		return fmt.Errorf("server is not running")
+	}
+	command = strings.TrimPrefix(strings.TrimSpace(command), "/")
+	if _, err := io.WriteString(proc.stdin, command+"\n"); err != nil {
+		return fmt.Errorf("failed to send command: %w", err)
+	}
+	return nil
+}
+
+// StopServer asks the server to save and stop, killing it if it has not
+// exited within ServerStopTimeout
+func (a *App) StopServer() error {
+	return a.stopServer(ServerStopTimeout)
+}
+
+func (a *App) stopServer(timeout time.Duration) error {
+	a.serverProcMu.Lock()
+	proc := a.serverProc
+	if proc != nil {
+		a.serverState = ServerStateStopping
+	}
+	a.serverProcMu.Unlock()
+	if proc == nil {
+		return nil
+	}
+
+	a.safeEventEmit("server-state", ServerStateStopping)
+	if err := a.SendServerCommand("stop"); err != nil {
+		proc.cmd.Process.Kill()
+	}
+
+	select {
+	case <-proc.exited:
+		return nil
+	case <-time.After(timeout):
+		a.safeEventEmit("log", fmt.Sprintf("Minecraft server did not stop within %v, killing it", timeout))
+		proc.cmd.Process.Kill()
+		<-proc.exited
+		return fmt.Errorf("server did not stop within %v and was killed", timeout)
+	}
+}
+
+// GetServerState returns stopped, starting, ready or stopping
+func (a *App) GetServerState() string {
+	a.serverProcMu.Lock()
+	defer a.serverProcMu.Unlock()
+	if a.serverState == "" {
+		return ServerStateStopped
+	}
+	return a.serverState
+}
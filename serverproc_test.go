@@ -0,0 +1,119 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeJava stands in for java: it ignores its arguments, logs a Done line,
+// and echoes stdin until it reads "stop" (or never stops if stubborn)
+func fakeJava(t *testing.T, stubborn bool) string {
+	t.Helper()
+	stop := `[ "$line" = "stop" ] && echo "Stopping the server" && exit 0`
+	if stubborn {
+		stop = ":"
+	}
+	script := "#!/bin/sh\n" +
+		`echo "[12:00:00] [Server thread/INFO]: Starting minecraft server"` + "\n" +
+		`echo "[12:00:01] [Server thread/INFO]: Done (1.234s)! For help, type \"help\""` + "\n" +
+		"while read line; do echo \"> $line\"; " + stop + "; done\n"
+	path := filepath.Join(t.TempDir(), "java")
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatalf("Failed to write fake java: %v", err)
+	}
+	return path
+}
+
+func TestServerCommand(t *testing.T) {
+	args, dir, err := serverCommand(ServerLaunchConfig{
+		JarPath:   "/srv/mc/server.jar",
+		MinMemory: "1G",
+		MaxMemory: "4G",
+		ExtraArgs: []string{"-XX:+UseG1GC"},
+	})
+	if err != nil {
+		t.Fatalf("serverCommand failed: %v", err)
+	}
+	want := []string{"java", "-Xms1G", "-Xmx4G", "-XX:+UseG1GC", "-jar", "/srv/mc/server.jar", "nogui"}
+	if !reflect.DeepEqual(args, want) || dir != "/srv/mc" {
+		t.Fatalf("Expected %v in /srv/mc, got %v in %s", want, args, dir)
+	}
+
+	if _, _, err := serverCommand(ServerLaunchConfig{}); err == nil {
+		t.Error("Expected error without a jar")
+	}
+}
+
+func TestServerLifecycle(t *testing.T) {
+	app := &App{ctx: testContext(), configDir: t.TempDir()}
+	config := ServerLaunchConfig{JavaPath: fakeJava(t, false), JarPath: "server.jar", WorkingDir: t.TempDir()}
+	if err := app.StartServer(config); err != nil {
+		t.Fatalf("StartServer failed: %v", err)
+	}
+	if err := app.StartServer(config); err == nil {
+		t.Error("Expected second start to fail while running")
+	}
+	saved, err := loadSettingsFile(filepath.Join(app.configDir, SettingsFileName))
+	if err != nil || !reflect.DeepEqual(saved.Server, config) {
+		t.Errorf("Expected the launch config to be saved, got %+v (%v)", saved.Server, err)
+	}
+
+	if !waitFor(t, 5*time.Second, func() bool { return app.GetServerState() == ServerStateReady }) {
+		t.Fatalf("Expected server to become ready, state %s", app.GetServerState())
+	}
+	if err := app.SendServerCommand("/say hi"); err != nil {
+		t.Fatalf("SendServerCommand failed: %v", err)
+	}
+
+	if err := app.StopServer(); err != nil {
+		t.Fatalf("StopServer failed: %v", err)
+	}
+	if app.GetServerState() != ServerStateStopped {
+		t.Fatalf("Expected stopped, got %s", app.GetServerState())
+	}
+	if err := app.SendServerCommand("list"); err == nil {
+		t.Error("Expected command to fail once stopped")
+	}
+}
+
+func TestStopServerKillsStubbornServer(t *testing.T) {
+	app := &App{ctx: testContext(), configDir: t.TempDir()}
+	if err := app.StartServer(ServerLaunchConfig{JavaPath: fakeJava(t, true), JarPath: "server.jar", WorkingDir: t.TempDir()}); err != nil {
+		t.Fatalf("StartServer failed: %v", err)
+	}
+
+	if err := app.stopServer(200 * time.Millisecond); err == nil {
+		t.Fatal("Expected an error for a server that had to be killed")
+	}
+	if app.GetServerState() != ServerStateStopped {
+		t.Fatalf("Expected stopped, got %s", app.GetServerState())
+	}
+}
+
+func TestServerConsoleSurvivesLongLines(t *testing.T) {
+	// A 100 KB line is still forwarded, a 2 MB one ends the console but
+	// must not stop the server from reading "stop"
+	script := "#!/bin/sh\n" +
+		"head -c 100000 /dev/zero | tr '\\0' x; echo\n" +
+		`echo "[12:00:01] [Server thread/INFO]: Done (1.234s)! For help, type \"help\""` + "\n" +
+		"head -c 2000000 /dev/zero | tr '\\0' x; echo\n" +
+		`while read line; do [ "$line" = "stop" ] && exit 0; done` + "\n"
+	java := filepath.Join(t.TempDir(), "java")
+	if err := os.WriteFile(java, []byte(script), 0o755); err != nil {
+		t.Fatalf("Failed to write fake java: %v", err)
+	}
+
+	app := &App{ctx: testContext(), configDir: t.TempDir()}
+	if err := app.StartServer(ServerLaunchConfig{JavaPath: java, JarPath: "server.jar", WorkingDir: t.TempDir()}); err != nil {
+		t.Fatalf("StartServer failed: %v", err)
+	}
+	if !waitFor(t, 5*time.Second, func() bool { return app.GetServerState() == ServerStateReady }) {
+		t.Fatalf("Expected the Done line after a long line to be seen, state %s", app.GetServerState())
+	}
+	if err := app.stopServer(5 * time.Second); err != nil {
+		t.Fatalf("Expected the server to read stop after an overlong line, got %v", err)
+	}
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	ServerPropertiesFile         = "server.properties"
+	ServerPropertiesPollInterval = 2 * time.Second
+
+	defaultServerPort = 25565
+	defaultRCONPort   = 25575
+)
+
+// ServerProperties are the settings we use from a server's server.properties
+type ServerProperties struct {
+	Directory    string `json:"directory"`
+	ServerPort   int    `json:"serverPort"`
+	ServerIP     string `json:"serverIp"`
+	MOTD         string `json:"motd"`
+	OnlineMode   bool   `json:"onlineMode"`
+	EnableRCON   bool   `json:"enableRcon"`
+	RCONPort     int    `json:"rconPort"`
+	RCONPassword string `json:"-"`
+	// Target is the address joiners are forwarded to, from server-ip and server-port
+	Target string `json:"target"`
+}
+
+// parseProperties reads a Java .properties file into a map
+func parseProperties(r io.Reader) (map[string]string, error) {
+	props := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	var pending string
+	for scanner.Scan() {
+		line := strings.TrimLeft(scanner.Text(), " \t\f")
+		if pending == "" && (line == "" || line[0] == '#' || line[0] == '!') {
+			continue
+		}
+		// An odd number of trailing backslashes continues the line
+		if trailing := len(line) - len(strings.TrimRight(line, `\`)); trailing%2 == 1 {
+			pending += line[:len(line)-1]
+			continue
+		}
+		line, pending = pending+line, ""
+
+		key, value := splitProperty(line)
+		props[unescapeProperty(key)] = unescapeProperty(value)
+	}
+	return props, scanner.Err()
+}
+
+// splitProperty splits at the first unescaped '=', ':' or whitespace
+func splitProperty(line string) (string, string) {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '=', ':', ' ', '\t', '\f':
+			key, rest := line[:i], strings.TrimLeft(line[i:], " \t\f")
+			if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+				rest = strings.TrimLeft(rest[1:], " \t\f")
+			}
+			return key, rest
+		}
+	}
+	return line, ""
+}
+
+func unescapeProperty(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+4 < len(s) {
+				if r, err := strconv.ParseUint(s[i+1:i+5], 16, 16); err == nil {
+					b.WriteRune(rune(r))
+					i += 4
+					continue
+				}
+			}
+			b.WriteByte('u')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// LoadServerProperties reads server.properties from a server directory,
+// filling in the server's defaults for missing keys
+func LoadServerProperties(dir string) (ServerProperties, error) {
+	f, err := os.Open(filepath.Join(dir, ServerPropertiesFile))
+	if err != nil {
+		return ServerProperties{}, err
+	}
+	defer f.Close()
+
+	props, err := parseProperties(f)
+	if err != nil {
+		return ServerProperties{}, fmt.Errorf("failed to read %s: %w", ServerPropertiesFile, err)
+	}
+
+	sp := ServerProperties{
+		Directory:    dir,
+		ServerPort:   defaultServerPort,
+		ServerIP:     props["server-ip"],
+		MOTD:         props["motd"],
+		OnlineMode:   props["online-mode"] != "false",
+		EnableRCON:   props["enable-rcon"] == "true",
+		RCONPort:     defaultRCONPort,
+		RCONPassword: props["rcon.password"],
+	}
+	if port, err := strconv.Atoi(props["server-port"]); err == nil && port > 0 && port <= 65535 {
+		sp.ServerPort = port
+	}
+	if port, err := strconv.Atoi(props["rcon.port"]); err == nil && port > 0 && port <= 65535 {
+		sp.RCONPort = port
+	}
+	sp.Target = sp.targetAddress()
+	return sp, nil
+}
+
+// targetAddress is where the host should forward joiners to
+func (sp ServerProperties) targetAddress() string {
+	host := sp.ServerIP
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	return net.JoinHostPort(host, strconv.Itoa(sp.ServerPort))
+}
+
+// applyServerProperties uses the properties for the forwarding target, the
+// MOTD sent to joiners and the RCON settings
+func (a *App) applyServerProperties(sp ServerProperties) {
+	a.SetTargetAddress(sp.targetAddress())
+
+	a.serverPropsMu.Lock()
+	a.serverProps = &sp
+	a.serverPropsMu.Unlock()
+
+	if sp.EnableRCON && sp.RCONPassword != "" {
+		a.SetRCONConfig(RCONConfig{
+			Address:  net.JoinHostPort("localhost", strconv.Itoa(sp.RCONPort)),
+			Password: sp.RCONPassword,
+		})
+	}
+
+	a.safeEventEmit("server-properties", sp)
+}
+
+// serverMOTD is the MOTD from server.properties, if a server directory is set
+func (a *App) serverMOTD() string {
+	a.serverPropsMu.Lock()
+	defer a.serverPropsMu.Unlock()
+	if a.serverProps == nil {
+		return ""
+	}
+	return a.serverProps.MOTD
+}
+
+// SetServerDirectory points the host at a Minecraft server directory. Its
+// server.properties configures the tunnel now and whenever the file changes.
+func (a *App) SetServerDirectory(dir string) (ServerProperties, error) {
+	// Read before parsing so a save in between is still seen as a change
+	seen, _ := os.ReadFile(filepath.Join(dir, ServerPropertiesFile))
+	sp, err := LoadServerProperties(dir)
+	if err != nil {
+		return ServerProperties{}, err
+	}
+
+	a.serverPropsMu.Lock()
+	if a.serverPropsStop != nil {
+		close(a.serverPropsStop)
+	}
+	stop := make(chan struct{})
+	a.serverPropsStop = stop
+	a.serverPropsMu.Unlock()
+
+	a.applyServerProperties(sp)
+	go a.watchServerProperties(dir, seen, stop)
+	return sp, nil
+}
+
+// ChooseServerDirectory asks for the server directory with a folder dialog
+// and uses it. Cancelling returns empty properties and no error.
+func (a *App) ChooseServerDirectory() (ServerProperties, error) {
+	if a.ctx == nil || a.ctx.Value(testModeKey) == true {
+		return ServerProperties{}, fmt.Errorf("folder dialog unavailable")
+	}
+	dir, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Choose your Minecraft server folder",
+	})
+	if err != nil || dir == "" {
+		return ServerProperties{}, err
+	}
+	return a.SetServerDirectory(dir)
+}
+
+// ClearServerDirectory stops following server.properties. The target it set
+// stays until another one is chosen.
+func (a *App) ClearServerDirectory() {
+	a.stopServerPropertiesWatch()
+	a.serverPropsMu.Lock()
+	a.serverProps = nil
+	a.serverPropsMu.Unlock()
+}
+
+// GetServerProperties returns the properties last read from the server directory
+func (a *App) GetServerProperties() (ServerProperties, error) {
+	a.serverPropsMu.Lock()
+	defer a.serverPropsMu.Unlock()
+	if a.serverProps == nil {
+		return ServerProperties{}, fmt.Errorf("no server directory set")
+	}
+	return *a.serverProps, nil
+}
+
+// stopServerPropertiesWatch stops watching the server directory
+func (a *App) stopServerPropertiesWatch() {
+	a.serverPropsMu.Lock()
+	defer a.serverPropsMu.Unlock()
+	if a.serverPropsStop != nil {
+		close(a.serverPropsStop)
+		a.serverPropsStop = nil
+	}
+}
+
+// watchServerProperties polls server.properties and re-applies it when its
+// contents differ from last, until stop is closed. Contents are compared rather than
+// modification times, which can be too coarse to see quick successive saves.
+func (a *App) watchServerProperties(dir string, last []byte, stop <-chan struct{}) {
+	path := filepath.Join(dir, ServerPropertiesFile)
+
+	ticker := time.NewTicker(ServerPropertiesPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil || bytes.Equal(data, last) {
+			continue
+		}
+		last = data
+
+		sp, err := LoadServerProperties(dir)
+		if err != nil {
+			a.safeEventEmit("log", fmt.Sprintf("Failed to reload %s: %v", ServerPropertiesFile, err))
+			continue
+		}
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		a.applyServerProperties(sp)
+		a.safeEventEmit("log", fmt.Sprintf("Reloaded %s", ServerPropertiesFile))
+	}
+}
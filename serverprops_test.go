@@ -0,0 +1,123 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testServerProperties = `#Minecraft server properties
+#Sat Oct 18 10:00:00 UTC 2026
+enable-rcon=true
+motd=§aFriends \= fun
+online-mode=false
+rcon.password=hunter2
+rcon.port=25580
+server-ip=
+server-port=25570
+`
+
+func writeServerProperties(t *testing.T, dir, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, ServerPropertiesFile), []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write server.properties: %v", err)
+	}
+}
+
+func TestParsePropertiesHandlesEscapesAndSeparators(t *testing.T) {
+	props, err := parseProperties(strings.NewReader("a = 1\nb:2\nc 3\n! comment\nlong=one \\\n  two\nkey\\=with\\:sep=x\n"))
+	if err != nil {
+		t.Fatalf("parseProperties failed: %v", err)
+	}
+	want := map[string]string{"a": "1", "b": "2", "c": "3", "long": "one two", "key=with:sep": "x"}
+	for k, v := range want {
+		if props[k] != v {
+			t.Errorf("%s: expected %q, got %q", k, v, props[k])
+		}
+	}
+}
+
+func TestLoadServerProperties(t *testing.T) {
+	dir := t.TempDir()
+	writeServerProperties(t, dir, testServerProperties)
+
+	sp, err := LoadServerProperties(dir)
+	if err != nil {
+		t.Fatalf("LoadServerProperties failed: %v", err)
+	}
+	if sp.ServerPort != 25570 || sp.RCONPort != 25580 || sp.RCONPassword != "hunter2" {
+		t.Errorf("Unexpected ports or password: %+v", sp)
+	}
+	if sp.MOTD != "§aFriends = fun" || sp.OnlineMode || !sp.EnableRCON {
+		t.Errorf("Unexpected MOTD or flags: %+v", sp)
+	}
+	if sp.Target != "localhost:25570" {
+		t.Errorf("Expected localhost:25570, got %s", sp.Target)
+	}
+}
+
+func TestLoadServerPropertiesDefaults(t *testing.T) {
+	dir := t.TempDir()
+	writeServerProperties(t, dir, "server-ip=192.168.1.5\n")
+
+	sp, err := LoadServerProperties(dir)
+	if err != nil {
+		t.Fatalf("LoadServerProperties failed: %v", err)
+	}
+	if sp.targetAddress() != "192.168.1.5:25565" || sp.RCONPort != 25575 || !sp.OnlineMode {
+		t.Errorf("Expected vanilla defaults, got %+v", sp)
+	}
+}
+
+func TestSetServerDirectoryConfiguresHost(t *testing.T) {
+	dir := t.TempDir()
+	writeServerProperties(t, dir, testServerProperties)
+	app := &App{ctx: testContext()}
+	defer app.stopServerPropertiesWatch()
+
+	if _, err := app.SetServerDirectory(dir); err != nil {
+		t.Fatalf("SetServerDirectory failed: %v", err)
+	}
+	if app.target() != "localhost:25570" {
+		t.Errorf("Expected target from server-port, got %s", app.target())
+	}
+	if rcon := app.GetRCONConfig(); rcon.Address != "localhost:25580" || rcon.Password != "hunter2" {
+		t.Errorf("Expected RCON settings from properties, got %+v", rcon)
+	}
+	if app.serverMOTD() != "§aFriends = fun" {
+		t.Errorf("Expected MOTD from properties, got %q", app.serverMOTD())
+	}
+
+	writeServerProperties(t, dir, strings.Replace(testServerProperties, "server-port=25570", "server-port=25599", 1))
+	// Polling target() while the watcher reloads also checks, under -race,
+	// that the two never touch the address unsynchronised
+	if !waitFor(t, 3*ServerPropertiesPollInterval, func() bool {
+		return app.target() == "localhost:25599"
+	}) {
+		t.Fatalf("Expected the reloaded target, got %s", app.target())
+	}
+	if sp, err := app.GetServerProperties(); err != nil || sp.ServerPort != 25599 {
+		t.Errorf("Expected changed server.properties to be reloaded, got %+v (%v)", sp, err)
+	}
+
+	app.ClearServerDirectory()
+	if _, err := app.GetServerProperties(); err == nil {
+		t.Error("Expected no server properties after clearing the directory")
+	}
+	writeServerProperties(t, dir, testServerProperties)
+	time.Sleep(2 * ServerPropertiesPollInterval)
+	if app.target() != "localhost:25599" {
+		t.Errorf("Expected the cleared directory not to be followed, got %s", app.target())
+	}
+}
+
+func TestAnnounceMOTDFallsBackToServerProperties(t *testing.T) {
+	app := &App{ctx: testContext()}
+	app.status = &cachedStatus{update: statusUpdate{Online: false, MOTD: "Offline [but] named"}, updatedAt: time.Now()}
+
+	if got := app.announceMOTD(); got != "Offline (but) named" {
+		t.Fatalf("Expected MOTD from host properties, got %q", got)
+	}
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pion/webrtc/v3"
+)
+
+const (
+	// SettingsVersion is the current settings file layout. Bump it and add a
+	// step to migrateSettings whenever a field is renamed or changes meaning.
+	SettingsVersion = 1
+
+	SettingsFileName = "settings.json"
+	appConfigDirName = "minecraft-tunnel"
+
+	DefaultICEServer = "stun:stun.l.google.com:19302"
+
+	maxDisplayNameLength = 32
+)
+
+// Settings is everything the app persists between runs
+type Settings struct {
+	Version       int      `json:"version"`
+	DisplayName   string   `json:"displayName"`
+	JoinerPort    int      `json:"joinerPort"`
+	TargetAddress string   `json:"targetAddress"`
+	ICEServers    []string `json:"iceServers"`
+	// SignalingServer is the mailbox server used to reconnect to known peers
+	SignalingServer string `json:"signalingServer,omitempty"`
+	// NATProbeServers (host:port) replace DefaultNATProbeServers for DetectNAT
+	NATProbeServers []string `json:"natProbeServers,omitempty"`
+	// WatchClipboard offers to accept tokens as soon as they are copied
+	WatchClipboard bool `json:"watchClipboard,omitempty"`
+	// Server is the launch config last used by StartServer
+	Server   ServerLaunchConfig `json:"server"`
+	Timeouts TimeoutSettings    `json:"timeouts"`
+	Security SecuritySettings   `json:"security"`
+}
+
+// TimeoutSettings overrides the defaults in timeout.go, in seconds
+type TimeoutSettings struct {
+	ICEGatheringSeconds int `json:"iceGatheringSeconds"`
+	TCPConnectSeconds   int `json:"tcpConnectSeconds"`
+	TCPOperationSeconds int `json:"tcpOperationSeconds"`
+}
+
+// SecuritySettings are the host's access controls
+type SecuritySettings struct {
+	Allowlist     AllowlistConfig `json:"allowlist"`
+	ProxyProtocol bool            `json:"proxyProtocol"`
+}
+
+// DefaultSettings is what a fresh install starts with
+func DefaultSettings() Settings {
+	port, _ := strconv.Atoi(DefaultJoinerPort)
+	return Settings{
+		Version:       SettingsVersion,
+		JoinerPort:    port,
+		TargetAddress: DefaultTargetAddress,
+		ICEServers:    []string{DefaultICEServer},
+		Timeouts: TimeoutSettings{
+			ICEGatheringSeconds: int(defaultTimeoutWebRTCICE / time.Second),
+			TCPConnectSeconds:   int(defaultTimeoutTCPConnect / time.Second),
+			TCPOperationSeconds: int(defaultTimeoutTCPOperation / time.Second),
+		},
+		Security: SecuritySettings{Allowlist: AllowlistConfig{Reason: DefaultAllowlistReason}},
+	}
+}
+
+func validateSettings(s Settings) error {
+	if s.JoinerPort <= 0 || s.JoinerPort > 65535 {
+		return fmt.Errorf("invalid joiner port %d", s.JoinerPort)
+	}
+	if _, _, err := net.SplitHostPort(s.TargetAddress); err != nil {
+		return fmt.Errorf("invalid server address %q: %w", s.TargetAddress, err)
+	}
+	for _, url := range s.ICEServers {
+		// TURN needs credentials, which the settings do not hold yet
+		if !strings.HasPrefix(url, "stun:") && !strings.HasPrefix(url, "stuns:") {
+			return fmt.Errorf("invalid ICE server %q: must start with stun: or stuns:", url)
+		}
+	}
+	for _, server := range s.NATProbeServers {
+		_, port, err := net.SplitHostPort(server)
+		if _, perr := strconv.Atoi(port); err != nil || perr != nil {
+			return fmt.Errorf("invalid NAT probe server %q: must be host:port", server)
+		}
+	}
+	if s.SignalingServer != "" {
+		u, err := url.Parse(s.SignalingServer)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid signaling server %q: must be an http or https URL", s.SignalingServer)
+		}
+	}
+	t := s.Timeouts
+	if t.ICEGatheringSeconds <= 0 || t.TCPConnectSeconds <= 0 || t.TCPOperationSeconds <= 0 {
+		return fmt.Errorf("timeouts must be at least one second")
+	}
+	if utf8.RuneCountInString(s.DisplayName) > maxDisplayNameLength {
+		return fmt.Errorf("display name is longer than %d characters", maxDisplayNameLength)
+	}
+	return nil
+}
+
+// migrateSettings upgrades a settings file in place from older versions.
+// Fields missing from old files keep the defaults they were decoded onto.
+func migrateSettings(s *Settings) error {
+	if s.Version > SettingsVersion {
+		return fmt.Errorf("settings were written by a newer version (%d)", s.Version)
+	}
+	// Version 0 is a file without a version field; its layout matches version 1
+	if s.Version < 1 {
+		s.Version = 1
+	}
+	return nil
+}
+
+// configFile is a file in the app's directory under the user config directory
+func (a *App) configFile(name string) (string, error) {
+	dir := a.configDir
+	if dir == "" {
+		base, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(base, appConfigDirName)
+	}
+	return filepath.Join(dir, name), nil
+}
+
+func (a *App) settingsPath() (string, error) {
+	return a.configFile(SettingsFileName)
+}
+
+// loadSettingsFile reads the settings file, returning the defaults when it
+// does not exist yet
+func loadSettingsFile(path string) (Settings, error) {
+	s := DefaultSettings()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+
+	// Decode onto the defaults so fields added since the file was written keep them
+	s.Version = 0
+	if err := json.Unmarshal(data, &s); err != nil {
+		return DefaultSettings(), fmt.Errorf("invalid settings file: %w", err)
+	}
+	if err := migrateSettings(&s); err != nil {
+		return DefaultSettings(), err
+	}
+	if err := validateSettings(s); err != nil {
+		return DefaultSettings(), err
+	}
+	return s, nil
+}
+
+func saveSettingsFile(path string, s Settings) error {
+	return writeConfigJSON(path, s)
+}
+
+// writeConfigJSON writes atomically so a crash never leaves a torn file
+func writeConfigJSON(path string, v interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadSettings reads and applies the settings file at startup. A broken or
+// too-new file is reported and left untouched; the defaults are used instead.
+func (a *App) loadSettings() {
+	path, err := a.settingsPath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[WARN] Settings unavailable: %v\n", err)
+		a.applySettings(DefaultSettings())
+		return
+	}
+	s, err := loadSettingsFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[WARN] Using default settings, could not load %s: %v\n", path, err)
+	}
+	a.applySettings(s)
+}
+
+// applySettings makes the settings take effect. The target is only set when
+// the saved address changed, so one picked for this session with
+// SelectLANWorld, SetServerDirectory or a profile survives other saves.
+func (a *App) applySettings(s Settings) {
+	a.settingsMu.Lock()
+	prev := a.settings
+	a.settings = &s
+	a.settingsMu.Unlock()
+
+	if prev == nil || prev.TargetAddress != s.TargetAddress {
+		a.SetTargetAddress(s.TargetAddress)
+	}
+	a.setAllowlist(s.Security.Allowlist)
+	a.proxyProtocol.Store(s.Security.ProxyProtocol)
+	if s.WatchClipboard {
+		a.StartClipboardWatch()
+	} else {
+		a.StopClipboardWatch()
+	}
+
+	TimeoutWebRTCICE.Store(time.Duration(s.Timeouts.ICEGatheringSeconds) * time.Second)
+	TimeoutTCPConnect.Store(time.Duration(s.Timeouts.TCPConnectSeconds) * time.Second)
+	TimeoutTCPOperation.Store(time.Duration(s.Timeouts.TCPOperationSeconds) * time.Second)
+}
+
+// GetSettings returns the current settings
+func (a *App) GetSettings() Settings {
+	a.settingsMu.Lock()
+	defer a.settingsMu.Unlock()
+	if a.settings == nil {
+		return DefaultSettings()
+	}
+	return *a.settings
+}
+
+// UpdateSettings validates, saves and applies new settings
+func (a *App) UpdateSettings(s Settings) error {
+	s.Version = SettingsVersion
+	if err := validateSettings(s); err != nil {
+		return err
+	}
+	path, err := a.settingsPath()
+	if err != nil {
+		return fmt.Errorf("no settings directory: %w", err)
+	}
+	if err := saveSettingsFile(path, s); err != nil {
+		return fmt.Errorf("failed to save settings: %w", err)
+	}
+	a.applySettings(s)
+	return nil
+}
+
+// saveSettingsChange applies change to the current settings and saves them,
+// for setters that have already made the change take effect themselves
+func (a *App) saveSettingsChange(change func(*Settings)) error {
+	a.settingsMu.Lock()
+	defer a.settingsMu.Unlock()
+	s := DefaultSettings()
+	if a.settings != nil {
+		s = *a.settings
+	}
+	change(&s)
+	a.settings = &s
+
+	path, err := a.settingsPath()
+	if err != nil {
+		return fmt.Errorf("no settings directory: %w", err)
+	}
+	if err := saveSettingsFile(path, s); err != nil {
+		return fmt.Errorf("failed to save settings: %w", err)
+	}
+	return nil
+}
+
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+// joinerPort is the local port the joiner's Minecraft client connects to,
+// from the active profile if it sets one
+func (a *App) joinerPort() string {
+	if p := a.GetActiveProfile(); p != nil && p.ListenPort != 0 {
+		return strconv.Itoa(p.ListenPort)
+	}
+	return strconv.Itoa(a.GetSettings().JoinerPort)
+}
+
+// iceServers builds the WebRTC ICE server list from the active profile, or
+// from the settings when no profile is active or it lists none
+func (a *App) iceServers() []webrtc.ICEServer {
+	urls := a.GetSettings().ICEServers
+	if p := a.GetActiveProfile(); p != nil && len(p.ICEServers) > 0 {
+		urls = p.ICEServers
+	}
+	if len(urls) == 0 {
+		return nil
+	}
+	return []webrtc.ICEServer{{URLs: urls}}
+}
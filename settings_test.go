@@ -0,0 +1,185 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// restoreTimeouts undoes the global timeout changes made by applySettings
+func restoreTimeouts(t *testing.T) {
+	ice, connect, op := TimeoutWebRTCICE.Load(), TimeoutTCPConnect.Load(), TimeoutTCPOperation.Load()
+	t.Cleanup(func() {
+		TimeoutWebRTCICE.Store(ice)
+		TimeoutTCPConnect.Store(connect)
+		TimeoutTCPOperation.Store(op)
+	})
+}
+
+func TestLoadSettingsFileDefaultsWhenMissing(t *testing.T) {
+	s, err := loadSettingsFile(filepath.Join(t.TempDir(), SettingsFileName))
+	if err != nil {
+		t.Fatalf("Expected no error for a missing file, got %v", err)
+	}
+	if !reflect.DeepEqual(s, DefaultSettings()) {
+		t.Fatalf("Expected defaults, got %+v", s)
+	}
+}
+
+func TestSettingsRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", SettingsFileName)
+	s := DefaultSettings()
+	s.DisplayName = "Alex"
+	s.JoinerPort = 40000
+	s.Security.Allowlist = AllowlistConfig{Enabled: true, Players: []string{"Steve"}, Reason: "Friends only"}
+
+	if err := saveSettingsFile(path, s); err != nil {
+		t.Fatalf("saveSettingsFile failed: %v", err)
+	}
+	loaded, err := loadSettingsFile(path)
+	if err != nil {
+		t.Fatalf("loadSettingsFile failed: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, s) {
+		t.Fatalf("Expected %+v, got %+v", s, loaded)
+	}
+}
+
+func TestLoadSettingsFileMigratesUnversionedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), SettingsFileName)
+	os.WriteFile(path, []byte(`{"displayName":"Alex","targetAddress":"localhost:25565"}`), 0o600)
+
+	s, err := loadSettingsFile(path)
+	if err != nil {
+		t.Fatalf("loadSettingsFile failed: %v", err)
+	}
+	if s.Version != SettingsVersion || s.DisplayName != "Alex" || s.TargetAddress != "localhost:25565" {
+		t.Errorf("Expected migrated values, got %+v", s)
+	}
+	if s.JoinerPort != 42517 || len(s.ICEServers) != 1 {
+		t.Errorf("Expected defaults for missing fields, got %+v", s)
+	}
+}
+
+func TestLoadSettingsFileRejectsNewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), SettingsFileName)
+	os.WriteFile(path, []byte(`{"version":99,"displayName":"Future"}`), 0o600)
+
+	s, err := loadSettingsFile(path)
+	if err == nil {
+		t.Fatal("Expected error for a newer settings version")
+	}
+	if s.DisplayName != "" {
+		t.Errorf("Expected defaults, got %+v", s)
+	}
+}
+
+func TestUpdateSettingsValidates(t *testing.T) {
+	app := &App{ctx: testContext(), configDir: t.TempDir()}
+	cases := map[string]func(*Settings){
+		"bad port":     func(s *Settings) { s.JoinerPort = 0 },
+		"bad target":   func(s *Settings) { s.TargetAddress = "localhost" },
+		"turn server":  func(s *Settings) { s.ICEServers = []string{"turn:turn.example.com"} },
+		"probe server": func(s *Settings) { s.NATProbeServers = []string{"stun.example.com"} },
+		"zero timeout": func(s *Settings) { s.Timeouts.TCPConnectSeconds = 0 },
+	}
+	for name, mutate := range cases {
+		s := DefaultSettings()
+		mutate(&s)
+		if err := app.UpdateSettings(s); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(app.configDir, SettingsFileName)); err == nil {
+		t.Error("Expected invalid settings not to be saved")
+	}
+}
+
+func TestUpdateSettingsSavesAndApplies(t *testing.T) {
+	restoreTimeouts(t)
+	app := &App{ctx: testContext(), configDir: t.TempDir()}
+	s := DefaultSettings()
+	s.JoinerPort = 40001
+	s.TargetAddress = "localhost:25565"
+	s.Timeouts.TCPConnectSeconds = 3
+	s.Security.ProxyProtocol = true
+
+	if err := app.UpdateSettings(s); err != nil {
+		t.Fatalf("UpdateSettings failed: %v", err)
+	}
+	if app.joinerPort() != "40001" || app.target() != "localhost:25565" || !app.GetProxyProtocol() {
+		t.Errorf("Expected settings to be applied, got port %s target %s", app.joinerPort(), app.target())
+	}
+	if TimeoutTCPConnect.Load() != 3*time.Second {
+		t.Errorf("Expected TCP connect timeout of 3s, got %v", TimeoutTCPConnect.Load())
+	}
+
+	restarted := &App{ctx: testContext(), configDir: app.configDir}
+	restarted.loadSettings()
+	if restarted.GetSettings().JoinerPort != 40001 {
+		t.Errorf("Expected settings to survive a restart, got %+v", restarted.GetSettings())
+	}
+}
+
+func TestSettingsKeepLiveTargetAndSecurity(t *testing.T) {
+	restoreTimeouts(t)
+	app := &App{ctx: testContext(), configDir: t.TempDir()}
+	app.loadSettings()
+
+	// A target picked for the session, such as a LAN world
+	app.SetTargetAddress("192.168.1.20:51234")
+	if err := app.SetAllowlist(AllowlistConfig{Enabled: true, Players: []string{"Steve"}}); err != nil {
+		t.Fatalf("SetAllowlist failed: %v", err)
+	}
+	if err := app.SetProxyProtocol(true); err != nil {
+		t.Fatalf("SetProxyProtocol failed: %v", err)
+	}
+
+	s := app.GetSettings()
+	s.DisplayName = "Alex"
+	if err := app.UpdateSettings(s); err != nil {
+		t.Fatalf("UpdateSettings failed: %v", err)
+	}
+	if app.target() != "192.168.1.20:51234" {
+		t.Errorf("Expected the session target to survive a save, got %s", app.target())
+	}
+	if !app.GetAllowlist().Enabled || !app.GetProxyProtocol() {
+		t.Error("Expected security changes made through setters to survive a save")
+	}
+
+	restarted := &App{ctx: testContext(), configDir: app.configDir}
+	restarted.loadSettings()
+	if cfg := restarted.GetAllowlist(); !cfg.Enabled || len(cfg.Players) != 1 || !restarted.GetProxyProtocol() {
+		t.Errorf("Expected security changes to be saved, got %+v", restarted.GetSettings().Security)
+	}
+
+	s.TargetAddress = "localhost:25566"
+	app.UpdateSettings(s)
+	if app.target() != "localhost:25566" {
+		t.Errorf("Expected a changed target to apply, got %s", app.target())
+	}
+}
+
+func TestChatCarriesDisplayName(t *testing.T) {
+	host := &App{ctx: testContext(), settings: &Settings{DisplayName: "Alex", ICEServers: []string{DefaultICEServer}}}
+	joiner := &App{ctx: testContext()}
+	connectTestApps(t, host, joiner)
+
+	if !waitFor(t, 5*time.Second, func() bool {
+		s := joiner.currentControl()
+		return s != nil && s.peerDisplayName() == "Alex"
+	}) {
+		t.Fatal("Expected joiner to learn the host's display name")
+	}
+	if _, err := joiner.SendChatMessage("hi Alex"); err != nil {
+		t.Fatalf("SendChatMessage failed: %v", err)
+	}
+	if !waitFor(t, 5*time.Second, func() bool { return len(host.GetChatHistory()) == 1 }) {
+		t.Fatal("Expected host to receive chat")
+	}
+	if from := host.GetChatHistory()[0].From; from != "" {
+		t.Errorf("Expected no name for a joiner without one, got %q", from)
+	}
+}
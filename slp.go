@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultTargetAddress = "localhost:42517"
+
+	packetHandshake     = 0x00
+	packetStatusRequest = 0x00
+	packetStatusPing    = 0x01
+
+	handshakeStateStatus   = 1
+	handshakeStateLogin    = 2
+	handshakeStateTransfer = 3 // 1.20.5+, a login arriving from another server
+
+	// Protocol version sent in status handshakes; servers answer status
+	// requests regardless of version
+	slpProtocolVersion = -1
+)
+
+// ServerStatus is the result of a Minecraft Server List Ping
+type ServerStatus struct {
+	Address       string  `json:"address"`
+	Version       string  `json:"version"`
+	Protocol      int     `json:"protocol"`
+	MOTD          string  `json:"motd"`
+	OnlinePlayers int     `json:"onlinePlayers"`
+	MaxPlayers    int     `json:"maxPlayers"`
+	LatencyMillis float64 `json:"latencyMillis"`
+
+	// Raw is the status JSON exactly as the server sent it
+	Raw string `json:"-"`
+}
+
+type slpResponse struct {
+	Version struct {
+		Name     string `json:"name"`
+		Protocol int    `json:"protocol"`
+	} `json:"version"`
+	Players struct {
+		Max    int `json:"max"`
+		Online int `json:"online"`
+	} `json:"players"`
+	Description json.RawMessage `json:"description"`
+}
+
+// chatText flattens a chat component (plain string or {"text", "extra"}) to text
+func chatText(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	var component struct {
+		Text  string            `json:"text"`
+		Extra []json.RawMessage `json:"extra"`
+	}
+	if err := json.Unmarshal(raw, &component); err != nil {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString(component.Text)
+	for _, e := range component.Extra {
+		sb.WriteString(chatText(e))
+	}
+	return sb.String()
+}
+
+func encodeHandshake(protocol int32, host string, port uint16, nextState int32) []byte {
+	payload := appendVarInt(nil, protocol)
+	payload = appendString(payload, host)
+	payload = binary.BigEndian.AppendUint16(payload, port)
+	return appendVarInt(payload, nextState)
+}
+
+func parseStatus(raw string) (ServerStatus, error) {
+	var resp slpResponse
+	if err := json.Unmarshal([]byte(raw), &resp); err != nil {
+		return ServerStatus{}, fmt.Errorf("invalid status response: %w", err)
+	}
+	return ServerStatus{
+		Version:       resp.Version.Name,
+		Protocol:      resp.Version.Protocol,
+		MOTD:          chatText(resp.Description),
+		OnlinePlayers: resp.Players.Online,
+		MaxPlayers:    resp.Players.Max,
+		Raw:           raw,
+	}, nil
+}
+
+// PingServer performs the Server List Ping handshake against address
+func PingServer(address string, timeout time.Duration) (ServerStatus, error) {
+	return pingServer(address, timeout, false)
+}
+
+// pingServer is PingServer for a server that expects a PROXY protocol header.
+// The ping is the tunnel's own connection, so it is sent as a LOCAL command.
+func pingServer(address string, timeout time.Duration, proxyHeader bool) (ServerStatus, error) {
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return ServerStatus{}, fmt.Errorf("invalid server address %q: %w", address, err)
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return ServerStatus{}, fmt.Errorf("invalid server port %q: %w", portStr, err)
+	}
+
+	conn, err := DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return ServerStatus{}, fmt.Errorf("Minecraft server at %s is not reachable: %w", address, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	if proxyHeader {
+		if _, err := conn.Write(proxyV2Header(nil, nil, "")); err != nil {
+			return ServerStatus{}, err
+		}
+	}
+	if err := writePacket(conn, packetHandshake, encodeHandshake(slpProtocolVersion, host, uint16(port), handshakeStateStatus)); err != nil {
+		return ServerStatus{}, err
+	}
+	if err := writePacket(conn, packetStatusRequest, nil); err != nil {
+		return ServerStatus{}, err
+	}
+
+	r := bufio.NewReader(conn)
+	id, payload, err := readPacket(r)
+	if err != nil {
+		return ServerStatus{}, fmt.Errorf("no status response from %s: %w", address, err)
+	}
+	if id != packetStatusRequest {
+		return ServerStatus{}, fmt.Errorf("unexpected packet 0x%02x in status response", id)
+	}
+	raw, err := readString(bytes.NewReader(payload))
+	if err != nil {
+		return ServerStatus{}, err
+	}
+
+	status, err := parseStatus(raw)
+	if err != nil {
+		return ServerStatus{}, err
+	}
+	status.Address = address
+
+	// Latency is best effort; some proxies close right after the status response
+	start := time.Now()
+	ping := binary.BigEndian.AppendUint64(nil, uint64(start.UnixMilli()))
+	if err := writePacket(conn, packetStatusPing, ping); err == nil {
+		if id, _, err := readPacket(r); err == nil && id == packetStatusPing {
+			status.LatencyMillis = float64(time.Since(start).Microseconds()) / 1000
+		}
+	}
+
+	return status, nil
+}
+
+// SetTargetAddress sets the host:port of the Minecraft server the host forwards to
+func (a *App) SetTargetAddress(address string) error {
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return fmt.Errorf("invalid server address %q: %w", address, err)
+	}
+	a.targetMu.Lock()
+	a.targetAddress = address
+	a.targetMu.Unlock()
+	return nil
+}
+
+// target is the address the host forwards to: the active profile's, or else
+// the one set by SetTargetAddress. It is read by the tunnel and status
+// goroutines while watchServerProperties may be changing it.
+func (a *App) target() string {
+	if p := a.GetActiveProfile(); p != nil && p.TargetAddress != "" {
+		return p.TargetAddress
+	}
+	a.targetMu.Lock()
+	defer a.targetMu.Unlock()
+	if a.targetAddress == "" {
+		return DefaultTargetAddress
+	}
+	return a.targetAddress
+}
+
+// PingMinecraftServer checks that the configured target answers a Server List Ping
+func (a *App) PingMinecraftServer() (ServerStatus, error) {
+	return a.pingTarget()
+}
+
+// pingTarget pings the target the way the tunnel connects to it, with a PROXY
+// header when the proxy protocol is enabled
+func (a *App) pingTarget() (ServerStatus, error) {
+	return pingServer(a.target(), TimeoutTCPOperation.Load(), a.proxyProtocol.Load())
+}
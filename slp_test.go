@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testStatusJSON = `{"version":{"name":"1.20.4","protocol":765},"players":{"max":20,"online":3},"description":{"text":"A ","extra":[{"text":"Minecraft Server"}]}}`
+
+// startFakeMinecraftServer answers Server List Ping requests with statusJSON
+func startFakeMinecraftServer(t *testing.T, statusJSON string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start fake Minecraft server: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				if _, _, err := readPacket(r); err != nil {
+					return
+				}
+				if _, _, err := readPacket(r); err != nil {
+					return
+				}
+				writePacket(conn, packetStatusRequest, appendString(nil, statusJSON))
+				id, payload, err := readPacket(r)
+				if err != nil || id != packetStatusPing {
+					return
+				}
+				writePacket(conn, packetStatusPing, payload)
+			}(conn)
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+func TestPingServerParsesStatus(t *testing.T) {
+	addr := startFakeMinecraftServer(t, testStatusJSON)
+
+	status, err := PingServer(addr, time.Second)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if status.Version != "1.20.4" || status.Protocol != 765 {
+		t.Fatalf("Unexpected version: %+v", status)
+	}
+	if status.MOTD != "A Minecraft Server" {
+		t.Fatalf("Expected flattened MOTD, got %q", status.MOTD)
+	}
+	if status.OnlinePlayers != 3 || status.MaxPlayers != 20 {
+		t.Fatalf("Unexpected players: %+v", status)
+	}
+}
+
+func TestPingServerFailsWhenServerIsDown(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to reserve port: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	if _, err := PingServer(addr, 500*time.Millisecond); err == nil {
+		t.Fatal("Expected error when no server is listening")
+	}
+}
+
+func TestChatTextAcceptsPlainString(t *testing.T) {
+	if got := chatText([]byte(`"Hello"`)); got != "Hello" {
+		t.Fatalf("Expected 'Hello', got %q", got)
+	}
+}
+
+func TestEncodeHandshakeLayout(t *testing.T) {
+	payload := encodeHandshake(765, "localhost", 25565, handshakeStateStatus)
+	r := bytes.NewReader(payload)
+
+	protocol, _ := readVarInt(r)
+	host, _ := readString(r)
+	port, _ := readUint16(r)
+	next, _ := readVarInt(r)
+	if protocol != 765 || host != "localhost" || port != 25565 || next != handshakeStateStatus {
+		t.Fatalf("Unexpected handshake fields: %d %q %d %d", protocol, host, port, next)
+	}
+}
+
+func TestPingMinecraftServerUsesTargetAddress(t *testing.T) {
+	addr := startFakeMinecraftServer(t, testStatusJSON)
+
+	app := &App{ctx: testContext()}
+	if err := app.SetTargetAddress(addr); err != nil {
+		t.Fatalf("SetTargetAddress failed: %v", err)
+	}
+	status, err := app.PingMinecraftServer()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if status.Address != addr {
+		t.Fatalf("Expected address %s, got %s", addr, status.Address)
+	}
+}
+
+func TestSetTargetAddressRejectsMissingPort(t *testing.T) {
+	app := &App{ctx: testContext()}
+	if err := app.SetTargetAddress("localhost"); err == nil {
+		t.Fatal("Expected error for address without port")
+	}
+}
+
+func TestCreateOfferNeedsReachableServer(t *testing.T) {
+	app := &App{ctx: testContext()}
+	app.SetTargetAddress(net.JoinHostPort("127.0.0.1", strconv.Itoa(freePort(t))))
+
+	if _, err := app.CreateOffer(); err == nil || !strings.Contains(err.Error(), "not reachable") {
+		t.Fatalf("Expected the pre-flight to refuse the offer, got: %v", err)
+	}
+	if app.peerConnection != nil {
+		t.Error("Expected no peer connection for a refused offer")
+	}
+}
+
+// startProxiedMinecraftServer is startFakeMinecraftServer behind a listener
+// that drops connections not starting with a PROXY v2 header
+func startProxiedMinecraftServer(t *testing.T, statusJSON string) string {
+	t.Helper()
+	upstream := startFakeMinecraftServer(t, statusJSON)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start proxied server: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				header := make([]byte, 16)
+				if _, err := io.ReadFull(conn, header); err != nil || !bytes.Equal(header[:12], proxyV2Signature) {
+					return
+				}
+				if _, err := io.CopyN(io.Discard, conn, int64(binary.BigEndian.Uint16(header[14:]))); err != nil {
+					return
+				}
+				up, err := net.Dial("tcp", upstream)
+				if err != nil {
+					return
+				}
+				defer up.Close()
+				go io.Copy(up, conn)
+				io.Copy(conn, up)
+			}(conn)
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+func TestPingTargetSendsProxyHeader(t *testing.T) {
+	app := &App{ctx: testContext()}
+	app.SetTargetAddress(startProxiedMinecraftServer(t, testStatusJSON))
+
+	if _, err := app.PingMinecraftServer(); err == nil {
+		t.Fatal("Expected the ping to fail without a PROXY header")
+	}
+
+	app.proxyProtocol.Store(true)
+	status, err := app.PingMinecraftServer()
+	if err != nil {
+		t.Fatalf("Expected the ping to pass with a PROXY header, got: %v", err)
+	}
+	if status.Version != "1.20.4" {
+		t.Fatalf("Unexpected status: %+v", status)
+	}
+}
+
+// startTestTarget gives app a fake Minecraft server to pass the host
+// pre-flight, unless the test already chose a target
+func startTestTarget(t *testing.T, app *App) {
+	t.Helper()
+	if app.targetAddress != "" {
+		return
+	}
+	if err := app.SetTargetAddress(startFakeMinecraftServer(t, testStatusJSON)); err != nil {
+		t.Fatalf("SetTargetAddress failed: %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+)
+
+const StatsSampleInterval = 2 * time.Second
+
+// TunnelStats is the snapshot emitted on the "stats" event and returned by GetStats
+type TunnelStats struct {
+	Timestamp           int64   `json:"timestamp"`
+	RTTMillis           float64 `json:"rttMillis"`
+	BytesSent           uint64  `json:"bytesSent"`
+	BytesReceived       uint64  `json:"bytesReceived"`
+	BytesSentPerSec     float64 `json:"bytesSentPerSec"`
+	BytesReceivedPerSec float64 `json:"bytesReceivedPerSec"`
+	CandidateType       string  `json:"candidateType"`
+	BufferedAmount      uint64  `json:"bufferedAmount"`
+	ActiveStreams       int32   `json:"activeStreams"`
+}
+
+// trafficCounters are bumped by the proxies for every chunk they move
+type trafficCounters struct {
+	bytesSent     atomic.Uint64
+	bytesReceived atomic.Uint64
+	activeStreams atomic.Int32
+}
+
+func (c *trafficCounters) addSent(n int) {
+	c.bytesSent.Add(uint64(n))
+}
+
+func (c *trafficCounters) addReceived(n int) {
+	c.bytesReceived.Add(uint64(n))
+}
+
+// computeRates fills in per-second rates relative to the previous sample
+func computeRates(prev, cur TunnelStats, elapsed time.Duration) TunnelStats {
+	secs := elapsed.Seconds()
+	if secs <= 0 {
+		return cur
+	}
+	if cur.BytesSent >= prev.BytesSent {
+		cur.BytesSentPerSec = float64(cur.BytesSent-prev.BytesSent) / secs
+	}
+	if cur.BytesReceived >= prev.BytesReceived {
+		cur.BytesReceivedPerSec = float64(cur.BytesReceived-prev.BytesReceived) / secs
+	}
+	return cur
+}
+
+// collectStats reads the peer connection and our own counters without computing rates
+func (a *App) collectStats() (TunnelStats, error) {
+	stats := TunnelStats{
+		Timestamp:     time.Now().UnixMilli(),
+		BytesSent:     a.traffic.bytesSent.Load(),
+		BytesReceived: a.traffic.bytesReceived.Load(),
+		ActiveStreams: a.traffic.activeStreams.Load(),
+	}
+
+	pc := a.peerConnection
+	if pc == nil {
+		return stats, fmt.Errorf("no active peer connection")
+	}
+
+	if dc := a.dataChannel; dc != nil {
+		stats.BufferedAmount = dc.BufferedAmount()
+	}
+
+	for _, s := range pc.GetStats() {
+		pair, ok := s.(webrtc.ICECandidatePairStats)
+		if !ok || !pair.Nominated || pair.State != webrtc.StatsICECandidatePairStateSucceeded {
+			continue
+		}
+		stats.RTTMillis = pair.CurrentRoundTripTime * 1000
+		break
+	}
+
+	if pair := a.selectedCandidatePair(); pair != nil {
+		stats.CandidateType = pair.Local.Typ.String()
+	}
+
+	return stats, nil
+}
+
+// selectedCandidatePair is the ICE pair carrying the tunnel, or nil before connecting
+func (a *App) selectedCandidatePair() *webrtc.ICECandidatePair {
+	pc := a.peerConnection
+	if pc == nil {
+		return nil
+	}
+	sctp := pc.SCTP()
+	if sctp == nil || sctp.Transport() == nil {
+		return nil
+	}
+	pair, err := sctp.Transport().ICETransport().GetSelectedCandidatePair()
+	if err != nil {
+		return nil
+	}
+	return pair
+}
+
+// GetStats returns the latest connection statistics for on-demand polling
+func (a *App) GetStats() (TunnelStats, error) {
+	a.statsMu.Lock()
+	prev, prevAt := a.lastStats, a.lastStatsAt
+	a.statsMu.Unlock()
+
+	cur, err := a.collectStats()
+	if err != nil {
+		return cur, err
+	}
+	if prevAt.IsZero() {
+		return cur, nil
+	}
+	return computeRates(prev, cur, time.Since(prevAt)), nil
+}
+
+// startStatsSampler emits a "stats" event every StatsSampleInterval until done is closed
+func (a *App) startStatsSampler(done <-chan struct{}) {
+	ticker := time.NewTicker(StatsSampleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		cur, err := a.collectStats()
+		if err != nil {
+			return
+		}
+
+		a.statsMu.Lock()
+		if !a.lastStatsAt.IsZero() {
+			cur = computeRates(a.lastStats, cur, time.Since(a.lastStatsAt))
+		}
+		a.lastStats, a.lastStatsAt = cur, time.Now()
+		a.statsMu.Unlock()
+
+		a.safeEventEmit("stats", cur)
+	}
+}
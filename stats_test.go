@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestComputeRatesUsesElapsedTime(t *testing.T) {
+	prev := TunnelStats{BytesSent: 1000, BytesReceived: 500}
+	cur := TunnelStats{BytesSent: 3000, BytesReceived: 1500}
+
+	got := computeRates(prev, cur, 2*time.Second)
+	if got.BytesSentPerSec != 1000 {
+		t.Fatalf("Expected 1000 B/s sent, got %v", got.BytesSentPerSec)
+	}
+	if got.BytesReceivedPerSec != 500 {
+		t.Fatalf("Expected 500 B/s received, got %v", got.BytesReceivedPerSec)
+	}
+}
+
+func TestComputeRatesIgnoresZeroElapsed(t *testing.T) {
+	cur := TunnelStats{BytesSent: 3000}
+	got := computeRates(TunnelStats{}, cur, 0)
+	if got.BytesSentPerSec != 0 {
+		t.Fatalf("Expected no rate for zero elapsed, got %v", got.BytesSentPerSec)
+	}
+}
+
+func TestGetStatsWithoutPeerConnection(t *testing.T) {
+	app := &App{ctx: testContext()}
+	if _, err := app.GetStats(); err == nil {
+		t.Fatal("Expected error when no peer connection exists")
+	}
+}
+
+func TestGetStatsReportsTrafficCounters(t *testing.T) {
+	app := &App{ctx: testContext()}
+	startTestTarget(t, app)
+	if _, err := app.CreateOffer(); err != nil {
+		t.Fatalf("Failed to create offer: %v", err)
+	}
+	defer app.shutdown(context.Background())
+
+	app.traffic.addSent(128)
+	app.traffic.addReceived(64)
+	app.traffic.activeStreams.Add(1)
+
+	stats, err := app.GetStats()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if stats.BytesSent != 128 || stats.BytesReceived != 64 {
+		t.Fatalf("Unexpected byte counters: %+v", stats)
+	}
+	if stats.ActiveStreams != 1 {
+		t.Fatalf("Expected 1 active stream, got %d", stats.ActiveStreams)
+	}
+}
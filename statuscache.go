@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+)
+
+const StatusRefreshInterval = 10 * time.Second
+
+// statusUpdate is what the host sends in "status" control messages
+type statusUpdate struct {
+	Online        bool    `json:"online"`
+	Status        string  `json:"status,omitempty"`
+	LatencyMillis float64 `json:"latencyMillis"`
+
+	// MOTD from the host's server.properties, used when the status has none
+	MOTD string `json:"motd,omitempty"`
+}
+
+// cachedStatus is the joiner's copy of the host server's last status
+type cachedStatus struct {
+	update    statusUpdate
+	updatedAt time.Time
+}
+
+// startStatusBroadcaster pings the target server and sends the result to the
+// joiner every StatusRefreshInterval until done is closed
+func (a *App) startStatusBroadcaster(s *controlSession, done <-chan struct{}) {
+	ticker := time.NewTicker(StatusRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		update := statusUpdate{MOTD: a.serverMOTD()}
+		if status, err := a.pingTarget(); err == nil {
+			update.Online, update.Status, update.LatencyMillis = true, status.Raw, status.LatencyMillis
+		}
+		if err := s.send(controlMessage{Type: controlTypeStatus, Status: &update}); err != nil {
+			return
+		}
+
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// storeStatus caches a status update received from the host
+func (a *App) storeStatus(update statusUpdate) {
+	a.statusMu.Lock()
+	a.status = &cachedStatus{update: update, updatedAt: time.Now()}
+	a.statusMu.Unlock()
+}
+
+// placeholderStatus is served while no status from the host is available.
+// It echoes the client's protocol so the entry is not shown as incompatible.
+func placeholderStatus(protocol int32, motd string) string {
+	data, _ := json.Marshal(map[string]interface{}{
+		"version":     map[string]interface{}{"name": "minecraft-tunnel", "protocol": protocol},
+		"players":     map[string]int{"max": 0, "online": 0},
+		"description": map[string]string{"text": motd},
+	})
+	return string(data)
+}
+
+// tunnelRTT is the round trip time from the most recent stats sample
+func (a *App) tunnelRTT() time.Duration {
+	a.statsMu.Lock()
+	defer a.statsMu.Unlock()
+	return time.Duration(a.lastStats.RTTMillis * float64(time.Millisecond))
+}
+
+// serveCachedStatus answers a status request locally so multiplayer screen
+// pings never reach the tunnel. The pong is delayed by the tunnel RTT plus the
+// host's own ping so the displayed latency matches a real round trip.
+func (a *App) serveCachedStatus(conn net.Conn, r *bufio.Reader, hs handshake) error {
+	conn.SetDeadline(time.Now().Add(TimeoutTCPOperation.Load()))
+
+	id, _, err := readPacket(r)
+	if err != nil {
+		return err
+	}
+	if id != packetStatusRequest {
+		return fmt.Errorf("unexpected packet 0x%02x after status handshake", id)
+	}
+
+	a.statusMu.Lock()
+	cached := a.status
+	a.statusMu.Unlock()
+
+	var body string
+	var serverLatency time.Duration
+	switch {
+	case cached == nil:
+		body = placeholderStatus(hs.ProtocolVersion, "Waiting for server status from host...")
+	case !cached.update.Online:
+		body = placeholderStatus(hs.ProtocolVersion, "Host's Minecraft server is offline")
+	default:
+		body = cached.update.Status
+		serverLatency = time.Duration(cached.update.LatencyMillis * float64(time.Millisecond))
+	}
+
+	if err := writePacket(conn, packetStatusRequest, appendString(nil, body)); err != nil {
+		return err
+	}
+
+	id, payload, err := readPacket(r)
+	if err != nil {
+		// Clients may close without pinging
+		return nil
+	}
+	if id != packetStatusPing {
+		return fmt.Errorf("unexpected packet 0x%02x instead of status ping", id)
+	}
+	time.Sleep(a.tunnelRTT() + serverLatency)
+	return writePacket(conn, packetStatusPing, payload)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+)
+
+// pingThroughProxy performs a Server List Ping over conn and returns the status JSON
+func pingThroughProxy(t *testing.T, conn net.Conn) string {
+	t.Helper()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	writePacket(conn, packetHandshake, encodeHandshake(765, "localhost", 42517, handshakeStateStatus))
+	writePacket(conn, packetStatusRequest, nil)
+
+	r := bufio.NewReader(conn)
+	id, payload, err := readPacket(r)
+	if err != nil || id != packetStatusRequest {
+		t.Fatalf("Expected status response, got id=0x%02x err=%v", id, err)
+	}
+	body, err := readString(bytes.NewReader(payload))
+	if err != nil {
+		t.Fatalf("Invalid status string: %v", err)
+	}
+
+	writePacket(conn, packetStatusPing, []byte{1, 2, 3, 4, 5, 6, 7, 8})
+	id, pong, err := readPacket(r)
+	if err != nil || id != packetStatusPing || !bytes.Equal(pong, []byte{1, 2, 3, 4, 5, 6, 7, 8}) {
+		t.Fatalf("Expected pong echo, got id=0x%02x payload=%x err=%v", id, pong, err)
+	}
+	return body
+}
+
+func startTestJoinerProxy(t *testing.T, app *App) string {
+	t.Helper()
+	if err := app.StartJoinerProxy(&webrtc.DataChannel{}, "0"); err != nil {
+		t.Fatalf("StartJoinerProxy failed: %v", err)
+	}
+	t.Cleanup(func() { app.listener.Close() })
+	return app.listener.Addr().String()
+}
+
+func TestJoinerProxyAnswersStatusFromCache(t *testing.T) {
+	app := &App{ctx: testContext()}
+	app.status = &cachedStatus{update: statusUpdate{Online: true, Status: testStatusJSON}, updatedAt: time.Now()}
+	addr := startTestJoinerProxy(t, app)
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	if body := pingThroughProxy(t, conn); body != testStatusJSON {
+		t.Fatalf("Expected cached status, got %s", body)
+	}
+}
+
+func TestJoinerProxyServesPlaceholderWithoutCache(t *testing.T) {
+	app := &App{ctx: testContext()}
+	addr := startTestJoinerProxy(t, app)
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	status, err := parseStatus(pingThroughProxy(t, conn))
+	if err != nil {
+		t.Fatalf("Placeholder is not valid status JSON: %v", err)
+	}
+	if status.Protocol != 765 {
+		t.Fatalf("Expected placeholder to echo client protocol, got %d", status.Protocol)
+	}
+}
+
+func TestServeCachedStatusDelaysPongByRTT(t *testing.T) {
+	app := &App{ctx: testContext()}
+	app.lastStats.RTTMillis = 50
+	app.status = &cachedStatus{update: statusUpdate{Online: true, Status: testStatusJSON, LatencyMillis: 10}}
+
+	client, server := net.Pipe()
+	defer client.Close()
+	go func() {
+		defer server.Close()
+		r := bufio.NewReader(server)
+		_, payload, _ := readPacket(r)
+		hs, _ := parseHandshake(payload)
+		app.serveCachedStatus(server, r, hs)
+	}()
+
+	start := time.Now()
+	pingThroughProxy(t, client)
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Fatalf("Expected pong delayed by RTT and server latency, took %v", elapsed)
+	}
+}
@@ -4,17 +4,41 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"sync/atomic"
 	"time"
 )
 
 const (
-	TimeoutWebRTCICE    = 30 * time.Second
-	TimeoutTCPConnect   = 10 * time.Second
-	TimeoutTCPOperation = 5 * time.Second
-	TimeoutFileIO       = 5 * time.Second
-	TimeoutNetwork      = 10 * time.Second
+	defaultTimeoutWebRTCICE    = 30 * time.Second
+	defaultTimeoutTCPConnect   = 10 * time.Second
+	defaultTimeoutTCPOperation = 5 * time.Second
+
+	TimeoutFileIO   = 5 * time.Second
+	TimeoutNetwork  = 10 * time.Second
+	TimeoutNATProbe = 2 * time.Second
+)
+
+// Configurable through Settings.Timeouts. applySettings can change them
+// while connections are reading them, hence atomic.
+var (
+	TimeoutWebRTCICE    = newAtomicDuration(defaultTimeoutWebRTCICE)
+	TimeoutTCPConnect   = newAtomicDuration(defaultTimeoutTCPConnect)
+	TimeoutTCPOperation = newAtomicDuration(defaultTimeoutTCPOperation)
 )
 
+type atomicDuration struct {
+	v atomic.Int64
+}
+
+func newAtomicDuration(d time.Duration) *atomicDuration {
+	ad := &atomicDuration{}
+	ad.Store(d)
+	return ad
+}
+
+func (d *atomicDuration) Load() time.Duration   { return time.Duration(d.v.Load()) }
+func (d *atomicDuration) Store(v time.Duration) { d.v.Store(int64(v)) }
+
 func RunWithTimeout[T any](operation string, timeout time.Duration, fn func() (T, error)) (T, error) {
 	var zero T
 	result := make(chan T, 1)
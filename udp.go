@@ -0,0 +1,378 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v3"
+)
+
+const (
+	PortProtocolUDP = "udp"
+
+	// Each UDP mapping has one unordered, zero-retransmit DataChannel labelled "udp/<mapping id>"
+	udpChannelLabelPrefix = "udp/"
+
+	UDPSessionIdleTimeout = 60 * time.Second
+	udpSweepInterval      = 10 * time.Second
+
+	// Most sessions, and so upstream sockets, the host keeps per relay.
+	// Datagrams for further session ids are dropped until idle ones expire.
+	maxUDPSessions = 256
+
+	// Largest UDP payload we relay; DataChannel messages above ~16 KiB fragment poorly
+	maxDatagramSize = 16 * 1024
+
+	// Session 0 is never given to a client. pion reports a new channel open
+	// before the host has read its open message, and a datagram overtaking
+	// that message makes the host drop the channel, so the joiner relays
+	// nothing until the host has sent an empty session 0 frame. Each side
+	// answers the other's until it has heard from it.
+	udpReadySession  = 0
+	udpReadyInterval = 250 * time.Millisecond
+)
+
+var errShortDatagram = errors.New("datagram frame too short")
+
+// Datagrams are framed as a 4-byte session id followed by the payload. The
+// joiner assigns one session per client source address; the host gives each
+// session its own socket so the server can tell clients apart.
+func encodeDatagram(session uint32, payload []byte) []byte {
+	out := binary.BigEndian.AppendUint32(make([]byte, 0, 4+len(payload)), session)
+	return append(out, payload...)
+}
+
+func decodeDatagram(frame []byte) (uint32, []byte, error) {
+	if len(frame) < 4 {
+		return 0, nil, errShortDatagram
+	}
+	return binary.BigEndian.Uint32(frame[:4]), frame[4:], nil
+}
+
+func unreliableChannelInit() *webrtc.DataChannelInit {
+	ordered := false
+	maxRetransmits := uint16(0)
+	return &webrtc.DataChannelInit{Ordered: &ordered, MaxRetransmits: &maxRetransmits}
+}
+
+// udpSession is one client (joiner side) or one upstream socket (host side)
+type udpSession struct {
+	id       uint32
+	addr     *net.UDPAddr // joiner: client address
+	conn     *net.UDPConn // host: socket connected to the target
+	lastSeen time.Time
+}
+
+// udpRelay tracks sessions for one UDP mapping on either end of the tunnel
+type udpRelay struct {
+	app  *App
+	dc   *webrtc.DataChannel
+	conn *net.UDPConn // joiner: local listener
+
+	idleTimeout time.Duration
+
+	mu     sync.Mutex
+	byID   map[uint32]*udpSession
+	byAddr map[string]*udpSession
+	nextID uint32
+	done   chan struct{}
+	once   sync.Once
+
+	ready     chan struct{}
+	readyOnce sync.Once
+}
+
+func (a *App) newUDPRelay(dc *webrtc.DataChannel, conn *net.UDPConn) *udpRelay {
+	r := &udpRelay{
+		app:         a,
+		dc:          dc,
+		conn:        conn,
+		idleTimeout: UDPSessionIdleTimeout,
+		byID:        make(map[uint32]*udpSession),
+		byAddr:      make(map[string]*udpSession),
+		done:        make(chan struct{}),
+		ready:       make(chan struct{}),
+	}
+	go r.sweep()
+	return r
+}
+
+// Close stops the relay and closes every socket it owns
+func (r *udpRelay) Close() error {
+	r.once.Do(func() {
+		close(r.done)
+		if r.conn != nil {
+			r.conn.Close()
+		}
+		r.mu.Lock()
+		for id, s := range r.byID {
+			if s.conn != nil {
+				s.conn.Close()
+			}
+			delete(r.byID, id)
+		}
+		r.byAddr = make(map[string]*udpSession)
+		r.mu.Unlock()
+		r.dc.Close()
+	})
+	return nil
+}
+
+// sweep drops sessions idle for longer than idleTimeout
+func (r *udpRelay) sweep() {
+	ticker := time.NewTicker(udpSweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-ticker.C:
+			r.expire(time.Now())
+		}
+	}
+}
+
+func (r *udpRelay) expire(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, s := range r.byID {
+		if now.Sub(s.lastSeen) < r.idleTimeout {
+			continue
+		}
+		if s.conn != nil {
+			s.conn.Close()
+		}
+		if s.addr != nil {
+			delete(r.byAddr, s.addr.String())
+		}
+		delete(r.byID, id)
+	}
+}
+
+// markReady records that the other side can take datagrams and reports
+// whether this is the first time
+func (r *udpRelay) markReady() (first bool) {
+	r.readyOnce.Do(func() {
+		close(r.ready)
+		first = true
+	})
+	return first
+}
+
+// announceReady sends the ready frame until the joiner answers
+func (r *udpRelay) announceReady() {
+	ticker := time.NewTicker(udpReadyInterval)
+	defer ticker.Stop()
+	for {
+		r.dc.Send(encodeDatagram(udpReadySession, nil))
+		select {
+		case <-r.done:
+			return
+		case <-r.ready:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *udpRelay) sessionCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.byID)
+}
+
+// serveJoiner relays the target's replies to clients and, once the host is
+// ready, client datagrams through the tunnel
+func (r *udpRelay) serveJoiner() {
+	r.dc.OnMessage(func(msg webrtc.DataChannelMessage) {
+		id, payload, err := decodeDatagram(msg.Data)
+		if err != nil {
+			return
+		}
+		if id == udpReadySession {
+			r.dc.Send(encodeDatagram(udpReadySession, nil))
+			if r.markReady() {
+				go r.relayClients()
+			}
+			return
+		}
+		r.mu.Lock()
+		s, ok := r.byID[id]
+		if ok {
+			s.lastSeen = time.Now()
+		}
+		r.mu.Unlock()
+		if !ok {
+			return
+		}
+		r.app.traffic.addReceived(len(payload))
+		r.conn.WriteToUDP(payload, s.addr)
+	})
+}
+
+// relayClients reads client datagrams and sends them through the tunnel
+func (r *udpRelay) relayClients() {
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, addr, err := r.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+
+		r.mu.Lock()
+		s, ok := r.byAddr[addr.String()]
+		if !ok {
+			r.nextID++
+			s = &udpSession{id: r.nextID, addr: addr}
+			r.byAddr[addr.String()] = s
+			r.byID[s.id] = s
+		}
+		s.lastSeen = time.Now()
+		r.mu.Unlock()
+
+		if err := r.dc.Send(encodeDatagram(s.id, buf[:n])); err == nil {
+			r.app.traffic.addSent(n)
+		}
+	}
+}
+
+// serveHost relays tunnel datagrams to target, one socket per session
+func (r *udpRelay) serveHost(target string) {
+	r.dc.OnOpen(func() {
+		go r.announceReady()
+	})
+	r.dc.OnMessage(func(msg webrtc.DataChannelMessage) {
+		id, payload, err := decodeDatagram(msg.Data)
+		if err != nil {
+			return
+		}
+		r.markReady()
+		if id == udpReadySession {
+			return
+		}
+
+		r.mu.Lock()
+		s, ok := r.byID[id]
+		if ok {
+			s.lastSeen = time.Now()
+		}
+		r.mu.Unlock()
+		if !ok {
+			if s = r.openSession(id, target); s == nil {
+				return
+			}
+		}
+
+		r.app.traffic.addReceived(len(payload))
+		s.conn.Write(payload)
+	})
+}
+
+// openSession dials target for a new session, or returns nil once the relay
+// has maxUDPSessions. The dial happens outside r.mu so a slow lookup does not
+// hold up the other sessions.
+func (r *udpRelay) openSession(id uint32, target string) *udpSession {
+	if r.sessionCount() >= maxUDPSessions {
+		return nil
+	}
+	conn, err := dialUDP(target)
+	if err != nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	select {
+	case <-r.done:
+		conn.Close()
+		return nil
+	default:
+	}
+	if len(r.byID) >= maxUDPSessions {
+		conn.Close()
+		return nil
+	}
+	s := &udpSession{id: id, conn: conn, lastSeen: time.Now()}
+	r.byID[id] = s
+	go r.pumpUpstream(s)
+	return s
+}
+
+// pumpUpstream sends the target's replies for one session back to the joiner
+func (r *udpRelay) pumpUpstream(s *udpSession) {
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, err := s.conn.Read(buf)
+		if err != nil {
+			return
+		}
+		r.mu.Lock()
+		s.lastSeen = time.Now()
+		r.mu.Unlock()
+		if err := r.dc.Send(encodeDatagram(s.id, buf[:n])); err == nil {
+			r.app.traffic.addSent(n)
+		}
+	}
+}
+
+func dialUDP(target string) (*net.UDPConn, error) {
+	addr, err := net.ResolveUDPAddr("udp", target)
+	if err != nil {
+		return nil, err
+	}
+	return net.DialUDP("udp", nil, addr)
+}
+
+// startUDPMapping listens for datagrams on the joiner and opens the mapping's channel
+func (a *App) startUDPMapping(m PortMapping) error {
+	pc := a.peerConnection
+	if pc == nil {
+		return fmt.Errorf("no active peer connection")
+	}
+	addr, err := net.ResolveUDPAddr("udp", ":"+strconv.Itoa(m.ListenPort))
+	if err != nil {
+		return err
+	}
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return err
+	}
+	dc, err := pc.CreateDataChannel(udpChannelLabelPrefix+m.ID, unreliableChannelInit())
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	relay := a.newUDPRelay(dc, conn)
+	relay.serveJoiner()
+	dc.OnClose(func() {
+		relay.Close()
+	})
+
+	a.portMapMu.Lock()
+	a.mappingListeners = append(a.mappingListeners, relay)
+	a.portMapMu.Unlock()
+	return nil
+}
+
+// handleUDPChannel serves a joiner-opened UDP mapping channel on the host
+func (a *App) handleUDPChannel(dc *webrtc.DataChannel) {
+	id := strings.TrimPrefix(dc.Label(), udpChannelLabelPrefix)
+	m, ok := a.findPortMapping(id)
+	if !ok || m.Protocol != PortProtocolUDP {
+		rejectChannel(dc)
+		return
+	}
+
+	relay := a.newUDPRelay(dc, nil)
+	relay.serveHost(m.Target)
+	dc.OnClose(func() {
+		relay.Close()
+	})
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func startUDPEchoServer(t *testing.T) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start UDP echo server: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 2048)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			conn.WriteTo(buf[:n], addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestDatagramFraming(t *testing.T) {
+	frame := encodeDatagram(42, []byte("ping"))
+	id, payload, err := decodeDatagram(frame)
+	if err != nil || id != 42 || string(payload) != "ping" {
+		t.Fatalf("Expected session 42 with ping, got %d %q (%v)", id, payload, err)
+	}
+	if _, _, err := decodeDatagram([]byte{1, 2}); err != errShortDatagram {
+		t.Errorf("Expected errShortDatagram, got %v", err)
+	}
+}
+
+func TestUDPRelayExpiresIdleSessions(t *testing.T) {
+	r := &udpRelay{
+		idleTimeout: time.Minute,
+		byID:        map[uint32]*udpSession{},
+		byAddr:      map[string]*udpSession{},
+	}
+	now := time.Now()
+	stale := &udpSession{id: 1, addr: &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1000}, lastSeen: now.Add(-2 * time.Minute)}
+	fresh := &udpSession{id: 2, addr: &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 2000}, lastSeen: now}
+	for _, s := range []*udpSession{stale, fresh} {
+		r.byID[s.id] = s
+		r.byAddr[s.addr.String()] = s
+	}
+
+	r.expire(now)
+
+	if r.sessionCount() != 1 || r.byID[2] == nil {
+		t.Fatalf("Expected only the fresh session to remain, got %v", r.byID)
+	}
+	if _, ok := r.byAddr[stale.addr.String()]; ok {
+		t.Error("Expected stale address to be forgotten")
+	}
+}
+
+func TestUDPRelayLimitsSessions(t *testing.T) {
+	target := startUDPEchoServer(t)
+	r := &udpRelay{
+		byID: map[uint32]*udpSession{},
+		done: make(chan struct{}),
+	}
+	for id := uint32(1); id < maxUDPSessions; id++ {
+		r.byID[id] = &udpSession{id: id, lastSeen: time.Now()}
+	}
+
+	s := r.openSession(maxUDPSessions, target)
+	if s == nil {
+		t.Fatal("Expected a session below the limit")
+	}
+	defer s.conn.Close()
+	if r.openSession(maxUDPSessions+1, target) != nil {
+		t.Fatal("Expected no session beyond the limit")
+	}
+	if r.sessionCount() != maxUDPSessions {
+		t.Errorf("Expected %d sessions, got %d", maxUDPSessions, r.sessionCount())
+	}
+}
+
+func TestPortMappingForwardsUDPThroughTunnel(t *testing.T) {
+	echo := startUDPEchoServer(t)
+	port := freePort(t)
+
+	host := &App{ctx: testContext()}
+	if err := host.SetPortMappings([]PortMapping{
+		{ID: "voice", Name: "Voice chat", Protocol: PortProtocolUDP, ListenPort: port, Target: echo},
+	}); err != nil {
+		t.Fatalf("SetPortMappings failed: %v", err)
+	}
+	joiner := &App{ctx: testContext()}
+	connectTestApps(t, host, joiner)
+
+	// Each client must get its own replies even though they share the mapping
+	for _, msg := range []string{"client one", "client two"} {
+		conn, err := net.Dial("udp", "127.0.0.1:"+strconv.Itoa(port))
+		if err != nil {
+			t.Fatalf("Dial failed: %v", err)
+		}
+		defer conn.Close()
+
+		// Datagrams may be dropped before the channel opens, so keep resending
+		buf := make([]byte, 64)
+		got := false
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline) && !got; {
+			conn.Write([]byte(msg))
+			conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+			n, err := conn.Read(buf)
+			got = err == nil && bytes.Equal(buf[:n], []byte(msg))
+		}
+		if !got {
+			t.Fatalf("Expected %q echoed through tunnel", msg)
+		}
+	}
+}
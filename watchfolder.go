@@ -0,0 +1,248 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	WatchFolderPollInterval = time.Second
+
+	// How long the host waits for an answer before removing its offer, and
+	// the age past which joiners ignore an offer file
+	WatchFolderTimeout = 10 * time.Minute
+
+	watchFilePrefix    = "mctunnel-"
+	watchOfferSuffix   = ".offer"
+	watchAnswerSuffix  = ".answer"
+	watchSessionIDSize = 8
+)
+
+// folderWatch is the running host or joiner watcher
+type folderWatch struct {
+	stop chan struct{}
+	once sync.Once
+}
+
+func (w *folderWatch) close() {
+	w.once.Do(func() { close(w.stop) })
+}
+
+// Offers and answers are paired by session ID:
+// mctunnel-<id>.offer from the host, mctunnel-<id>.answer from the joiner
+func offerFileName(sessionID string) string  { return watchFilePrefix + sessionID + watchOfferSuffix }
+func answerFileName(sessionID string) string { return watchFilePrefix + sessionID + watchAnswerSuffix }
+
+// offerSessionID returns the session ID of an offer file name
+func offerSessionID(name string) (string, bool) {
+	id, ok := strings.CutPrefix(name, watchFilePrefix)
+	if !ok {
+		return "", false
+	}
+	id, ok = strings.CutSuffix(id, watchOfferSuffix)
+	return id, ok && id != ""
+}
+
+func newWatchSessionID() string {
+	b := make([]byte, watchSessionIDSize)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// writeTokenFile writes through a temporary name so sync tools and the other
+// side's watcher never see half a token
+func writeTokenFile(path, token string) error {
+	tmp := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err := os.WriteFile(tmp, []byte(token), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// startFolderWatch replaces any running watcher with a new one
+func (a *App) startFolderWatch() *folderWatch {
+	w := &folderWatch{stop: make(chan struct{})}
+	a.folderMu.Lock()
+	if a.folderWatch != nil {
+		a.folderWatch.close()
+	}
+	a.folderWatch = w
+	a.folderMu.Unlock()
+	return w
+}
+
+// finishFolderWatch clears w once its goroutine is done, unless a newer
+// watcher has replaced it
+func (a *App) finishFolderWatch(w *folderWatch) {
+	a.folderMu.Lock()
+	defer a.folderMu.Unlock()
+	w.close()
+	if a.folderWatch == w {
+		a.folderWatch = nil
+	}
+}
+
+// StopFolderSignaling stops waiting for offers or answers in the watch folder
+func (a *App) StopFolderSignaling() {
+	a.folderMu.Lock()
+	defer a.folderMu.Unlock()
+	if a.folderWatch != nil {
+		a.folderWatch.close()
+		a.folderWatch = nil
+	}
+}
+
+// HostViaFolder writes a new offer into dir and connects as soon as the
+// joiner's answer appears there. Both files are removed afterwards.
+func (a *App) HostViaFolder(dir string) (string, error) {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("watch folder %s is not a directory", dir)
+	}
+	offer, err := a.CreateOffer()
+	if err != nil {
+		return "", err
+	}
+	sessionID := newWatchSessionID()
+	offerPath := filepath.Join(dir, offerFileName(sessionID))
+	if err := writeTokenFile(offerPath, offer); err != nil {
+		return "", fmt.Errorf("failed to write offer: %w", err)
+	}
+
+	w := a.startFolderWatch()
+	a.safeEventEmit("log", fmt.Sprintf("Offer %s written to %s, waiting for an answer", sessionID, dir))
+	go a.awaitFolderAnswer(w, dir, sessionID)
+	return sessionID, nil
+}
+
+func (a *App) awaitFolderAnswer(w *folderWatch, dir, sessionID string) {
+	offerPath := filepath.Join(dir, offerFileName(sessionID))
+	answerPath := filepath.Join(dir, answerFileName(sessionID))
+	defer a.finishFolderWatch(w)
+	defer os.Remove(offerPath)
+
+	ticker := time.NewTicker(WatchFolderPollInterval)
+	defer ticker.Stop()
+	deadline := time.After(WatchFolderTimeout)
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-deadline:
+			a.safeEventEmit("log", fmt.Sprintf("No answer to offer %s within %v", sessionID, WatchFolderTimeout))
+			return
+		case <-ticker.C:
+		}
+
+		data, err := os.ReadFile(answerPath)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		os.Remove(answerPath)
+		if err != nil {
+			a.safeEventEmit("log", fmt.Sprintf("Failed to read answer %s: %v", sessionID, err))
+			return
+		}
+		if err := a.AcceptAnswer(strings.TrimSpace(string(data))); err != nil {
+			a.safeEventEmit("log", fmt.Sprintf("Answer %s rejected: %v", sessionID, err))
+			return
+		}
+		a.safeEventEmit("log", fmt.Sprintf("Picked up answer %s", sessionID))
+		return
+	}
+}
+
+// WatchFolderForOffers answers the first new offer that appears in dir and
+// then stops watching. Offers older than WatchFolderTimeout are ignored.
+func (a *App) WatchFolderForOffers(dir string) error {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return fmt.Errorf("watch folder %s is not a directory", dir)
+	}
+	w := a.startFolderWatch()
+	a.safeEventEmit("log", fmt.Sprintf("Watching %s for offers", dir))
+	go a.awaitFolderOffer(w, dir)
+	return nil
+}
+
+func (a *App) awaitFolderOffer(w *folderWatch, dir string) {
+	defer a.finishFolderWatch(w)
+	ticker := time.NewTicker(WatchFolderPollInterval)
+	defer ticker.Stop()
+	tried := map[string]bool{}
+	for {
+		for _, sessionID := range pendingOffers(dir, time.Now().Add(-WatchFolderTimeout)) {
+			if tried[sessionID] {
+				continue
+			}
+			tried[sessionID] = true
+			if a.answerFolderOffer(dir, sessionID) {
+				return
+			}
+		}
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// pendingOffers lists the session IDs of unanswered offers newer than
+// since, oldest first
+func pendingOffers(dir string, since time.Time) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	type offer struct {
+		id      string
+		modTime time.Time
+	}
+	var offers []offer
+	for _, e := range entries {
+		id, ok := offerSessionID(e.Name())
+		if !ok || e.IsDir() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || info.ModTime().Before(since) {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, answerFileName(id))); err == nil {
+			continue
+		}
+		offers = append(offers, offer{id, info.ModTime()})
+	}
+	sort.Slice(offers, func(i, j int) bool { return offers[i].modTime.Before(offers[j].modTime) })
+	ids := make([]string, len(offers))
+	for i, o := range offers {
+		ids[i] = o.id
+	}
+	return ids
+}
+
+// answerFolderOffer accepts one offer and writes the answer next to it
+func (a *App) answerFolderOffer(dir, sessionID string) bool {
+	data, err := os.ReadFile(filepath.Join(dir, offerFileName(sessionID)))
+	if err != nil {
+		return false
+	}
+	answer, err := a.AcceptOffer(strings.TrimSpace(string(data)))
+	if err != nil {
+		a.safeEventEmit("log", fmt.Sprintf("Skipping offer %s: %v", sessionID, err))
+		return false
+	}
+	if err := writeTokenFile(filepath.Join(dir, answerFileName(sessionID)), answer); err != nil {
+		a.safeEventEmit("log", fmt.Sprintf("Failed to write answer %s: %v", sessionID, err))
+		return false
+	}
+	a.safeEventEmit("log", fmt.Sprintf("Answered offer %s", sessionID))
+	return true
+}
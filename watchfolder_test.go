@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOfferSessionID(t *testing.T) {
+	if id, ok := offerSessionID(offerFileName("ab12")); !ok || id != "ab12" {
+		t.Errorf("Expected ab12, got %q %v", id, ok)
+	}
+	for _, name := range []string{"mctunnel-ab12.answer", "notes.offer", "mctunnel-.offer", ".mctunnel-ab12.offer.tmp"} {
+		if _, ok := offerSessionID(name); ok {
+			t.Errorf("Expected %s not to be an offer", name)
+		}
+	}
+}
+
+func TestPendingOffers(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, offerFileName("old")), []byte("x"), 0o644)
+	stale := time.Now().Add(-2 * WatchFolderTimeout)
+	os.Chtimes(filepath.Join(dir, offerFileName("old")), stale, stale)
+	os.WriteFile(filepath.Join(dir, offerFileName("answered")), []byte("x"), 0o644)
+	os.WriteFile(filepath.Join(dir, answerFileName("answered")), []byte("x"), 0o644)
+	os.WriteFile(filepath.Join(dir, offerFileName("fresh")), []byte("x"), 0o644)
+
+	got := pendingOffers(dir, time.Now().Add(-WatchFolderTimeout))
+	if len(got) != 1 || got[0] != "fresh" {
+		t.Errorf("Expected only the fresh offer, got %v", got)
+	}
+}
+
+func TestFolderSignaling(t *testing.T) {
+	dir := t.TempDir()
+	host := &App{ctx: testContext(), configDir: t.TempDir(), settings: &Settings{DisplayName: "Alex", ICEServers: []string{DefaultICEServer}}}
+	joiner := &App{ctx: testContext(), configDir: t.TempDir(), settings: &Settings{JoinerPort: freePort(t), ICEServers: []string{DefaultICEServer}}}
+	t.Cleanup(func() {
+		host.shutdown(context.Background())
+		joiner.shutdown(context.Background())
+	})
+
+	// A leftover offer nobody can answer is skipped
+	os.WriteFile(filepath.Join(dir, offerFileName("garbage")), []byte("not a token"), 0o644)
+
+	if err := joiner.WatchFolderForOffers(dir); err != nil {
+		t.Fatalf("WatchFolderForOffers failed: %v", err)
+	}
+	startTestTarget(t, host)
+	sessionID, err := host.HostViaFolder(dir)
+	if err != nil {
+		t.Fatalf("HostViaFolder failed: %v", err)
+	}
+
+	if !waitFor(t, 15*time.Second, func() bool {
+		s := joiner.currentControl()
+		return s != nil && s.peerDisplayName() == "Alex"
+	}) {
+		t.Fatal("Expected the tunnel to connect through the folder")
+	}
+	if !waitFor(t, 5*time.Second, func() bool {
+		_, offerErr := os.Stat(filepath.Join(dir, offerFileName(sessionID)))
+		_, answerErr := os.Stat(filepath.Join(dir, answerFileName(sessionID)))
+		return os.IsNotExist(offerErr) && os.IsNotExist(answerErr)
+	}) {
+		t.Error("Expected the host to clean up the session's files")
+	}
+
+	joiner.folderMu.Lock()
+	watching := joiner.folderWatch != nil
+	joiner.folderMu.Unlock()
+	if watching {
+		t.Error("Expected the joiner to stop watching after answering")
+	}
+}
+
+func TestFolderSignalingRejectsMissingDirectory(t *testing.T) {
+	app := &App{ctx: testContext()}
+	if err := app.WatchFolderForOffers(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Expected error for a missing folder")
+	}
+}